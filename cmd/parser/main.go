@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
@@ -15,14 +17,28 @@ import (
 	"gorm.io/gorm"
 
 	"trade_bot/internal/chat/client"
+	exchangeClient "trade_bot/internal/client"
+	"trade_bot/internal/order"
+	"trade_bot/internal/order/entry"
+	"trade_bot/internal/order/exit"
+	"trade_bot/internal/order/leverage"
+	"trade_bot/internal/order/liquidation"
+	orderRepository "trade_bot/internal/order/repository"
+	"trade_bot/internal/order/risk"
+	"trade_bot/internal/order/rules"
+	"trade_bot/internal/order/sizing"
 	"trade_bot/internal/signals"
 	"trade_bot/internal/signals/parser"
 	"trade_bot/internal/signals/repository"
+	commonTypes "trade_bot/internal/types"
 )
 
 const (
 	chatMessageTopic   string = "chat.income"
 	signalMessageTopic string = "signal.created"
+
+	managerInterval    time.Duration = 10 * time.Second
+	reconcilerInterval time.Duration = 30 * time.Second
 )
 
 func main() {
@@ -92,11 +108,107 @@ func main() {
 
 	log.Info("Waiting for messages...")
 
+	// initialize order processor
+	orderRepo, err := orderRepository.NewGormOrder(db)
+	if err != nil {
+		log.Fatalf("Failed to create order repository: %v", err)
+	}
+	rejectionRepo, err := orderRepository.NewGormRejection(db)
+	if err != nil {
+		log.Fatalf("Failed to create rejection repository: %v", err)
+	}
+	channels, err := newChannels()
+	if err != nil {
+		log.Fatalf("Invalid channel options: %v", err)
+	}
+	riskLimits, err := newRiskLimits()
+	if err != nil {
+		log.Fatalf("Invalid risk limits: %v", err)
+	}
+	liquidationGuard, err := newLiquidationGuard()
+	if err != nil {
+		log.Fatalf("Invalid liquidation options: %v", err)
+	}
+	exchangeClients := newExchangeClients()
+	orderNormalizer := rules.NewNormalizer()
+	orderStates := order.NewStateMachine(&order.StateMachineOptions{
+		OrderRepository: orderRepo,
+		Publisher:       pubSub,
+		Logger:          log,
+	})
+	orderProcessor := order.NewProcessor(&order.ProcessorOptions{
+		SignalTopic:      signalMessageTopic,
+		SignalSubscriber: pubSub,
+		OrderHandler: order.NewHandler(&order.HandlerOptions{
+			Clients:         exchangeClients,
+			OrderRepository: orderRepo,
+			OrderStates:     orderStates,
+			Normalizer:      orderNormalizer,
+			Risk: risk.NewManager(&risk.ManagerOptions{
+				Limits:              riskLimits,
+				OrderRepository:     orderRepo,
+				RejectionRepository: rejectionRepo,
+				Logger:              log,
+			}),
+			Liquidation: liquidationGuard,
+			Logger:      log,
+			Channels:    channels,
+		}),
+		Logger: log,
+	})
+	go func() {
+		if err := orderProcessor.Start(ctx); err != nil {
+			log.Fatalf("Failed to process signals: %v", err)
+		}
+	}()
+
+	// initialize position manager
+	positionManager := order.NewManager(&order.ManagerOptions{
+		Clients:         exchangeClients,
+		OrderRepository: orderRepo,
+		OrderStates:     orderStates,
+		Normalizer:      orderNormalizer,
+		Channels:        channels,
+		Interval:        managerInterval,
+		Logger:          log,
+	})
+	go func() {
+		if err := positionManager.Start(ctx); err != nil {
+			log.Fatalf("Failed to manage positions: %v", err)
+		}
+	}()
+
+	orderReconciler := order.NewReconciler(&order.ReconcilerOptions{
+		Clients:         exchangeClients,
+		OrderRepository: orderRepo,
+		OrderStates:     orderStates,
+		Interval:        reconcilerInterval,
+		Logger:          log,
+	})
+	go func() {
+		if err := orderReconciler.Start(ctx); err != nil {
+			log.Fatalf("Failed to reconcile orders: %v", err)
+		}
+	}()
+
 	// initialize message parser
 	signalRepository, err := repository.NewGormSignal(db)
 	if err != nil {
 		log.Fatalf("Failed to create signal repository: %v", err)
 	}
+	signalStatusTracker := signals.NewStatusTracker(&signals.StatusTrackerOptions{
+		OrderSubscriber:  pubSub,
+		ExpiredTopic:     order.TopicOrderExpired,
+		MissedTopic:      order.TopicOrderMissed,
+		SignalRepository: signalRepository,
+		Logger:           log,
+	})
+	go func() {
+		if err := signalStatusTracker.Start(ctx); err != nil {
+			log.Fatalf("Failed to track signal statuses: %v", err)
+		}
+	}()
+
 	parser := signals.NewParser(&signals.ParserOptions{
 		Handlers: []signals.Handler{
 			parser.NewHardcoreVIP(),
@@ -113,6 +225,196 @@ func main() {
 	}
 }
 
+// newChannels sets up how signals of every channel are traded
+func newChannels() (map[commonTypes.SignalChannel]*order.ChannelOptions, error) {
+	hardcoreVIPRisk, err := strconv.ParseFloat(os.Getenv("HARDCOREVIP_RISK_PERCENT"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HARDCOREVIP_RISK_PERCENT: %w", err)
+	}
+	hardcoreVIPTrailing, err := strconv.ParseFloat(os.Getenv("HARDCOREVIP_TRAILING_PERCENT"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HARDCOREVIP_TRAILING_PERCENT: %w", err)
+	}
+	hardcoreVIPSlippage, err := newSlippage("HARDCOREVIP")
+	if err != nil {
+		return nil, err
+	}
+	hardcoreVIPLeverage, err := newLeverage("HARDCOREVIP")
+	if err != nil {
+		return nil, err
+	}
+	hardcoreVIPEntryTTL, err := newEntryTTL("HARDCOREVIP")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[commonTypes.SignalChannel]*order.ChannelOptions{
+		commonTypes.SignalChannelHardcoreVIP: {
+			Sizer:        sizing.NewRisk(hardcoreVIPRisk),
+			EntryPlanner: entry.NewLadder(3, entry.DistributionWeighted),
+			StopRules: []order.StopRule{
+				exit.NewBreakEven(1),
+				exit.NewTrailingPercent(hardcoreVIPTrailing),
+			},
+			Slippage: hardcoreVIPSlippage,
+			Leverage: hardcoreVIPLeverage,
+			EntryTTL: hardcoreVIPEntryTTL,
+		},
+	}, nil
+}
+
+// newSlippage reads entry policy of channel, live price is not checked when policy is not set
+func newSlippage(prefix string) (*order.SlippageOptions, error) {
+	policy := order.EntryPolicy(os.Getenv(prefix + "_ENTRY_POLICY"))
+	switch policy {
+	case "":
+		return nil, nil
+	case order.EntryPolicySkip, order.EntryPolicyWait:
+		return &order.SlippageOptions{Policy: policy}, nil
+	case order.EntryPolicyMarket:
+		tolerance, err := strconv.ParseFloat(os.Getenv(prefix+"_ENTRY_TOLERANCE_PERCENT"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_ENTRY_TOLERANCE_PERCENT: %w", prefix, err)
+		}
+
+		return &order.SlippageOptions{Policy: policy, Tolerance: tolerance}, nil
+	default:
+		return nil, fmt.Errorf("invalid %s_ENTRY_POLICY: %q", prefix, policy)
+	}
+}
+
+// newLeverage reads leverage policy of channel, the lowest leverage of signal is taken when policy is not set
+func newLeverage(prefix string) (order.LeveragePolicy, error) {
+	policy := os.Getenv(prefix + "_LEVERAGE_POLICY")
+	switch policy {
+	case "":
+		return nil, nil
+	case "min":
+		return leverage.NewMin(), nil
+	case "max":
+		return leverage.NewMax(), nil
+	case "midpoint":
+		return leverage.NewMidpoint(), nil
+	case "fixed", "stop":
+	default:
+		return nil, fmt.Errorf("invalid %s_LEVERAGE_POLICY: %q", prefix, policy)
+	}
+
+	// fixed leverage or loss percent of margin at stop
+	value, err := strconv.ParseFloat(os.Getenv(prefix+"_LEVERAGE_VALUE"), 64)
+	if err != nil || value <= 0 {
+		return nil, fmt.Errorf("invalid %s_LEVERAGE_VALUE: %q", prefix, os.Getenv(prefix+"_LEVERAGE_VALUE"))
+	}
+	if policy == "fixed" {
+		return leverage.NewFixed(value), nil
+	}
+
+	return leverage.NewStopDistance(value), nil
+}
+
+// newEntryTTL reads how long entry of channel waits for fill, it waits until target or stop when not set
+func newEntryTTL(prefix string) (time.Duration, error) {
+	value := os.Getenv(prefix + "_ENTRY_TTL_MINUTES")
+	if value == "" {
+		return 0, nil
+	}
+
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 {
+		return 0, fmt.Errorf("invalid %s_ENTRY_TTL_MINUTES: %q", prefix, value)
+	}
+
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// newRiskLimits reads limits every position is checked against, limit not set is not checked
+func newRiskLimits() (risk.Limits, error) {
+	var limits risk.Limits
+
+	if value := os.Getenv("RISK_MAX_OPEN_POSITIONS"); value != "" {
+		maxOpenPositions, err := strconv.Atoi(value)
+		if err != nil {
+			return limits, fmt.Errorf("invalid RISK_MAX_OPEN_POSITIONS: %w", err)
+		}
+		limits.MaxOpenPositions = maxOpenPositions
+	}
+
+	floatLimits := map[string]*float64{
+		"RISK_MAX_SYMBOL_EXPOSURE":  &limits.MaxSymbolExposure,
+		"RISK_MAX_CHANNEL_EXPOSURE": &limits.MaxChannelExposure,
+		"RISK_MAX_LEVERAGE":         &limits.MaxLeverage,
+		"RISK_MAX_DAILY_LOSS":       &limits.MaxDailyLoss,
+	}
+	for name, limit := range floatLimits {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return limits, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*limit = parsed
+	}
+
+	if limits.MaxLeverage > 0 && limits.MaxLeverage < 1 {
+		return limits, fmt.Errorf("invalid RISK_MAX_LEVERAGE: %g is below 1", limits.MaxLeverage)
+	}
+
+	return limits, nil
+}
+
+// newLiquidationGuard reads margin mode positions are held in, isolated when not set.
+// Maintenance rate is used for exchanges not telling margin tiers of symbol.
+func newLiquidationGuard() (*liquidation.Guard, error) {
+	marginMode := commonTypes.MarginMode(os.Getenv("MARGIN_MODE"))
+	switch marginMode {
+	case "", commonTypes.MarginModeIsolated, commonTypes.MarginModeCross:
+	default:
+		return nil, fmt.Errorf("invalid MARGIN_MODE: %q", marginMode)
+	}
+
+	maintenanceRate, err := strconv.ParseFloat(os.Getenv("LIQUIDATION_MAINTENANCE_RATE_PERCENT"), 64)
+	if err != nil || maintenanceRate < 0 {
+		return nil, fmt.Errorf("invalid LIQUIDATION_MAINTENANCE_RATE_PERCENT: %q", os.Getenv("LIQUIDATION_MAINTENANCE_RATE_PERCENT"))
+	}
+
+	return liquidation.NewGuard(&liquidation.GuardOptions{
+		MarginMode:      marginMode,
+		MaintenanceRate: maintenanceRate / 100,
+	}), nil
+}
+
+func newExchangeClients() *exchangeClient.Registry {
+	registry := exchangeClient.NewRegistry(
+		exchangeClient.NewBybit(&exchangeClient.BybitOptions{
+			ApiKey:    os.Getenv("BYBIT_API_KEY"),
+			ApiSecret: os.Getenv("BYBIT_API_SECRET"),
+		}),
+		exchangeClient.NewBingx(&exchangeClient.BingxOptions{
+			ApiKey:    os.Getenv("BINGX_API_KEY"),
+			ApiSecret: os.Getenv("BINGX_API_SECRET"),
+		}),
+	)
+
+	// leveraged and short signals need contracts, spot is used otherwise
+	if os.Getenv("MEXC_FUTURES") == "true" {
+		registry.Register(exchangeClient.NewMexcFutures(&exchangeClient.MexcFuturesOptions{
+			ApiKey:     os.Getenv("MEXC_API_KEY"),
+			ApiSecret:  os.Getenv("MEXC_API_SECRET"),
+			MarginMode: commonTypes.MarginMode(os.Getenv("MARGIN_MODE")),
+		}))
+	} else {
+		registry.Register(exchangeClient.NewMexc(&exchangeClient.MexcOptions{
+			ApiKey:    os.Getenv("MEXC_API_KEY"),
+			ApiSecret: os.Getenv("MEXC_API_SECRET"),
+		}))
+	}
+
+	return registry
+}
+
 func StartTelegram(ctx context.Context, pubSub message.Publisher, log *logrus.Logger) {
 	// Load environment variables
 	appID, err := strconv.Atoi(os.Getenv("TELEGRAM_APP_ID"))
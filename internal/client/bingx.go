@@ -0,0 +1,566 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+const (
+	bingxBaseURL        string        = "https://open-api.bingx.com"
+	bingxDefaultTimeout time.Duration = 10 * time.Second
+)
+
+type bingxResponse struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+type priceBingx struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+type balanceBingx struct {
+	Balance struct {
+		Asset           string `json:"asset"`
+		Balance         string `json:"balance"`
+		AvailableMargin string `json:"availableMargin"`
+	} `json:"balance"`
+}
+
+// triggerBingx is take profit or stop loss attached to order
+type triggerBingx struct {
+	Type        string  `json:"type"`
+	StopPrice   float64 `json:"stopPrice"`
+	Price       float64 `json:"price"`
+	WorkingType string  `json:"workingType"`
+}
+
+type orderBingx struct {
+	Symbol        string      `json:"symbol"`
+	OrderID       json.Number `json:"orderId"`
+	ClientOrderID string      `json:"clientOrderId"`
+	Side          string      `json:"side"`
+	PositionSide  string      `json:"positionSide"`
+	Type          string      `json:"type"`
+	Quantity      string      `json:"origQty"`
+	Price         string      `json:"price"`
+	ExecutedQty   string      `json:"executedQty"`
+	AvgPrice      string      `json:"avgPrice"`
+	Commission    string      `json:"commission"`
+	Status        string      `json:"status"`
+}
+
+type orderResponseBingx struct {
+	Order orderBingx `json:"order"`
+}
+
+type positionBingx struct {
+	Symbol           string  `json:"symbol"`
+	PositionSide     string  `json:"positionSide"`
+	PositionAmt      string  `json:"positionAmt"`
+	AvgPrice         string  `json:"avgPrice"`
+	Leverage         float64 `json:"leverage"`
+	LiquidationPrice float64 `json:"liquidationPrice"`
+	UnrealizedProfit string  `json:"unrealizedProfit"`
+}
+
+type contractBingx struct {
+	Symbol            string  `json:"symbol"`
+	Asset             string  `json:"asset"`
+	Currency          string  `json:"currency"`
+	PricePrecision    int     `json:"pricePrecision"`
+	QuantityPrecision int     `json:"quantityPrecision"`
+	TradeMinQuantity  float64 `json:"tradeMinQuantity"`
+	TradeMinUSDT      float64 `json:"tradeMinUSDT"`
+}
+
+// bingxError is an error returned by BingX API in code and msg
+type bingxError struct {
+	Code    int
+	Message string
+}
+
+func (e *bingxError) Error() string {
+	return fmt.Sprintf("bingx error %d (%s): %s", e.Code, e.Category(), e.Message)
+}
+
+// Category groups BingX error codes
+func (e *bingxError) Category() ErrorCategory {
+	if category, ok := bingxErrorCategory[e.Code]; ok {
+		return category
+	}
+
+	return ErrorCategoryUnknown
+}
+
+var (
+	_ FuturesClient     = (*Bingx)(nil)
+	_ OrderLookupClient = (*Bingx)(nil)
+)
+
+// bingxOrderNotExistCode is returned when order is not found
+const bingxOrderNotExistCode = 80016
+
+// BingxOptions holds configuration options for the BingX client.
+type BingxOptions struct {
+	ApiKey     string
+	ApiSecret  string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Bingx is a client for BingX perpetual swap v2 API in hedge position mode.
+type Bingx struct {
+	apiKey     string
+	secretKey  string
+	baseUrl    string
+	httpClient *http.Client
+}
+
+var (
+	bingxPositionSide = map[commonTypes.Position]string{
+		commonTypes.PositionLong:  "LONG",
+		commonTypes.PositionShort: "SHORT",
+	}
+
+	bingxPosition = map[string]commonTypes.Position{
+		"LONG":  commonTypes.PositionLong,
+		"SHORT": commonTypes.PositionShort,
+	}
+
+	bingxOrderSide = map[string]commonTypes.OrderSide{
+		"BUY":  commonTypes.OrderSideLong,
+		"SELL": commonTypes.OrderSideShort,
+	}
+
+	// bingxOrderType maps order type to bingx order type and time in force
+	bingxOrderType = map[commonTypes.OrderType][2]string{
+		commonTypes.OrderTypeLimit:             {"LIMIT", ""},
+		commonTypes.OrderTypeMarket:            {"MARKET", ""},
+		commonTypes.OrderTypeImmediateOrCancel: {"LIMIT", "IOC"},
+		commonTypes.OrderTypeFillOrKill:        {"LIMIT", "FOK"},
+	}
+
+	bingxOrderStatus = map[string]commonTypes.OrderStatus{
+		"NEW":              commonTypes.OrderStatusNew,
+		"PENDING":          commonTypes.OrderStatusNew,
+		"PARTIALLY_FILLED": commonTypes.OrderStatusPartiallyFilled,
+		"FILLED":           commonTypes.OrderStatusFilled,
+		"CANCELED":         commonTypes.OrderStatusCanceled,
+		"CANCELLED":        commonTypes.OrderStatusCanceled,
+		"FAILED":           commonTypes.OrderStatusCanceled,
+		"EXPIRED":          commonTypes.OrderStatusCanceled,
+	}
+
+	bingxErrorCategory = map[int]ErrorCategory{
+		100001: ErrorCategoryAuth,      // signature verification failed
+		100413: ErrorCategoryAuth,      // incorrect api key
+		100419: ErrorCategoryAuth,      // ip not in whitelist
+		101204: ErrorCategoryBalance,   // insufficient margin
+		101415: ErrorCategorySymbol,    // trading pair suspended
+		100410: ErrorCategoryRateLimit, // rate limitation
+		100421: ErrorCategoryTimestamp, // timestamp mismatch
+	}
+)
+
+var (
+	ErrBingxOrderSideNotFound   = errors.New("bingx order side not found")
+	ErrBingxOrderTypeNotFound   = errors.New("bingx order type not found")
+	ErrBingxOrderStatusNotFound = errors.New("bingx order status not found")
+	ErrBingxSymbolNotFound      = errors.New("bingx symbol not found")
+)
+
+// NewBingx creates a new BingX client. Empty options are replaced with defaults.
+func NewBingx(opt *BingxOptions) *Bingx {
+	baseUrl := opt.BaseURL
+	if baseUrl == "" {
+		baseUrl = bingxBaseURL
+	}
+
+	httpClient := opt.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: bingxDefaultTimeout}
+	}
+
+	return &Bingx{
+		apiKey:     opt.ApiKey,
+		secretKey:  opt.ApiSecret,
+		baseUrl:    baseUrl,
+		httpClient: httpClient,
+	}
+}
+
+func (b *Bingx) Name() commonTypes.Exchange {
+	return commonTypes.ExchangeBingx
+}
+
+func (b *Bingx) GetPrice(ctx context.Context, symbol, baseSymbol string) (float64, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+
+	var price priceBingx
+	if err := b.doRequest(ctx, http.MethodGet, "/openApi/swap/v2/quote/price", queryParams, &price); err != nil {
+		return 0, fmt.Errorf("Bingx::GetPrice : %w", err)
+	}
+
+	floatValue, err := strconv.ParseFloat(price.Price, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse price to float: %w", err)
+	}
+
+	return floatValue, nil
+}
+
+func (b *Bingx) GetAssets(ctx context.Context, symbol string) (float64, error) {
+	var balance balanceBingx
+	if err := b.doRequest(ctx, http.MethodGet, "/openApi/swap/v2/user/balance", url.Values{}, &balance); err != nil {
+		return 0, fmt.Errorf("Bingx::GetAssets : %w", err)
+	}
+
+	if balance.Balance.Asset != symbol {
+		return 0, nil
+	}
+
+	floatValue, err := strconv.ParseFloat(balance.Balance.AvailableMargin, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Bingx::GetAssets : %w", err)
+	}
+
+	return floatValue, nil
+}
+
+// SetLeverage sets the same leverage for long and short side of symbol
+func (b *Bingx) SetLeverage(ctx context.Context, symbol, baseSymbol string, leverage float64) error {
+	for _, side := range []string{"LONG", "SHORT"} {
+		queryParams := url.Values{}
+		queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+		queryParams.Set("side", side)
+		queryParams.Set("leverage", strconv.FormatFloat(leverage, 'f', 0, 64))
+
+		if err := b.doRequest(ctx, http.MethodPost, "/openApi/swap/v2/trade/leverage", queryParams, nil); err != nil {
+			return fmt.Errorf("Bingx::SetLeverage : %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (b *Bingx) CreateFuturesOrder(ctx context.Context, order *types.FuturesOrder) (commonTypes.OrderID, error) {
+	positionSide, ok := bingxPositionSide[order.Position]
+	if !ok {
+		return "", ErrBingxOrderSideNotFound
+	}
+
+	orderType, ok := bingxOrderType[order.Type]
+	if !ok {
+		return "", ErrBingxOrderTypeNotFound
+	}
+
+	// in hedge mode position is opened by buying long or selling short and closed by the opposite side
+	side := "BUY"
+	if (order.Position == commonTypes.PositionShort) != order.ReduceOnly {
+		side = "SELL"
+	}
+
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(order.Symbol, order.BaseSymbol))
+	queryParams.Set("side", side)
+	queryParams.Set("positionSide", positionSide)
+	queryParams.Set("type", orderType[0])
+	queryParams.Set("quantity", strconv.FormatFloat(order.Quantity, 'f', -1, 64))
+	if orderType[1] != "" {
+		queryParams.Set("timeInForce", orderType[1])
+	}
+	if order.ClientOrderID != "" {
+		queryParams.Set("clientOrderID", order.ClientOrderID)
+	}
+	if order.Type != commonTypes.OrderTypeMarket {
+		queryParams.Set("price", strconv.FormatFloat(order.Entry, 'f', -1, 64))
+	}
+	if order.TakeProfit > 0 {
+		takeProfit, err := json.Marshal(&triggerBingx{
+			Type:        "TAKE_PROFIT_MARKET",
+			StopPrice:   order.TakeProfit,
+			Price:       order.TakeProfit,
+			WorkingType: "MARK_PRICE",
+		})
+		if err != nil {
+			return "", fmt.Errorf("Bingx::CreateFuturesOrder : %w", err)
+		}
+		queryParams.Set("takeProfit", string(takeProfit))
+	}
+	if order.StopLoss > 0 {
+		stopLoss, err := json.Marshal(&triggerBingx{
+			Type:        "STOP_MARKET",
+			StopPrice:   order.StopLoss,
+			Price:       order.StopLoss,
+			WorkingType: "MARK_PRICE",
+		})
+		if err != nil {
+			return "", fmt.Errorf("Bingx::CreateFuturesOrder : %w", err)
+		}
+		queryParams.Set("stopLoss", string(stopLoss))
+	}
+
+	var created orderResponseBingx
+	if err := b.doRequest(ctx, http.MethodPost, "/openApi/swap/v2/trade/order", queryParams, &created); err != nil {
+		return "", fmt.Errorf("Bingx::CreateFuturesOrder : %w", err)
+	}
+
+	return commonTypes.OrderID(created.Order.OrderID.String()), nil
+}
+
+func (b *Bingx) GetOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) (*commonTypes.Order, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+	queryParams.Set("orderId", string(orderID))
+
+	var orderRecv orderResponseBingx
+	if err := b.doRequest(ctx, http.MethodGet, "/openApi/swap/v2/trade/order", queryParams, &orderRecv); err != nil {
+		return nil, fmt.Errorf("Bingx::GetOrder : %w", err)
+	}
+
+	order, err := newOrderFromBingx(&orderRecv.Order)
+	if err != nil {
+		return nil, fmt.Errorf("Bingx::GetOrder : %w", err)
+	}
+
+	return order, nil
+}
+
+// GetOrderByClientID returns order placed with client order id, ErrOrderNotFound when there is none
+func (b *Bingx) GetOrderByClientID(ctx context.Context, symbol, baseSymbol, clientOrderID string) (*commonTypes.Order, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+	queryParams.Set("clientOrderId", clientOrderID)
+
+	var orderRecv orderResponseBingx
+	err := b.doRequest(ctx, http.MethodGet, "/openApi/swap/v2/trade/order", queryParams, &orderRecv)
+	var bingxErr *bingxError
+	if errors.As(err, &bingxErr) && bingxErr.Code == bingxOrderNotExistCode {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Bingx::GetOrderByClientID : %w", err)
+	}
+
+	order, err := newOrderFromBingx(&orderRecv.Order)
+	if err != nil {
+		return nil, fmt.Errorf("Bingx::GetOrderByClientID : %w", err)
+	}
+
+	return order, nil
+}
+
+func (b *Bingx) CancelOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) error {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+	queryParams.Set("orderId", string(orderID))
+
+	if err := b.doRequest(ctx, http.MethodDelete, "/openApi/swap/v2/trade/order", queryParams, nil); err != nil {
+		return fmt.Errorf("Bingx::CancelOrder : %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bingx) CancelAllOrders(ctx context.Context, symbol, baseSymbol string) error {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+
+	if err := b.doRequest(ctx, http.MethodDelete, "/openApi/swap/v2/trade/allOpenOrders", queryParams, nil); err != nil {
+		return fmt.Errorf("Bingx::CancelAllOrders : %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bingx) GetPositions(ctx context.Context, symbol, baseSymbol string) ([]*types.OpenPosition, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+
+	var positions []positionBingx
+	if err := b.doRequest(ctx, http.MethodGet, "/openApi/swap/v2/user/positions", queryParams, &positions); err != nil {
+		return nil, fmt.Errorf("Bingx::GetPositions : %w", err)
+	}
+
+	result := make([]*types.OpenPosition, 0, len(positions))
+	for _, p := range positions {
+		position, ok := bingxPosition[p.PositionSide]
+		if !ok {
+			continue
+		}
+
+		values, err := parseFloats(p.PositionAmt, p.AvgPrice, p.UnrealizedProfit)
+		if err != nil {
+			return nil, fmt.Errorf("Bingx::GetPositions : %w", err)
+		}
+
+		result = append(result, &types.OpenPosition{
+			Symbol:           symbol,
+			BaseSymbol:       baseSymbol,
+			Position:         position,
+			Quantity:         math.Abs(values[0]),
+			Entry:            values[1],
+			Leverage:         p.Leverage,
+			LiquidationPrice: p.LiquidationPrice,
+			UnrealisedPnl:    values[2],
+		})
+	}
+
+	return result, nil
+}
+
+func (b *Bingx) GetSymbolInfo(ctx context.Context, symbol, baseSymbol string) (*types.SymbolInfo, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", bingxSymbol(symbol, baseSymbol))
+
+	var contracts []contractBingx
+	if err := b.doRequest(ctx, http.MethodGet, "/openApi/swap/v2/quote/contracts", queryParams, &contracts); err != nil {
+		return nil, fmt.Errorf("Bingx::GetSymbolInfo : %w", err)
+	}
+
+	for _, contract := range contracts {
+		if contract.Symbol != bingxSymbol(symbol, baseSymbol) {
+			continue
+		}
+
+		return &types.SymbolInfo{
+			Symbol:      symbol,
+			BaseSymbol:  baseSymbol,
+			TickSize:    math.Pow10(-contract.PricePrecision),
+			StepSize:    math.Pow10(-contract.QuantityPrecision),
+			MinQuantity: contract.TradeMinQuantity,
+			MinNotional: contract.TradeMinUSDT,
+		}, nil
+	}
+
+	return nil, ErrBingxSymbolNotFound
+}
+
+// doRequest signs and sends request. Signature is calculated over sorted raw parameters
+// while values are url encoded in request query.
+func (b *Bingx) doRequest(ctx context.Context, method, path string, queryParams url.Values, result any) error {
+	queryParams.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+
+	keys := make([]string, 0, len(queryParams))
+	for key := range queryParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rawParams := make([]string, 0, len(keys))
+	encodedParams := make([]string, 0, len(keys))
+	for _, key := range keys {
+		rawParams = append(rawParams, key+"="+queryParams.Get(key))
+		encodedParams = append(encodedParams, key+"="+url.QueryEscape(queryParams.Get(key)))
+	}
+
+	mac := hmac.New(sha256.New, []byte(b.secretKey))
+	mac.Write([]byte(strings.Join(rawParams, "&")))
+
+	requestURL := fmt.Sprintf("%s%s?%s&signature=%s", b.baseUrl, path, strings.Join(encodedParams, "&"), hex.EncodeToString(mac.Sum(nil)))
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("doRequest : %w", err)
+	}
+	req.Header.Set("X-BX-APIKEY", b.apiKey)
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response : %w", err)
+	}
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 600 {
+		return fmt.Errorf("received error status %d with message: %s", resp.StatusCode, string(body))
+	}
+
+	var response bingxResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("doRequest : %w", err)
+	}
+	if response.Code != 0 {
+		return &bingxError{Code: response.Code, Message: response.Msg}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Data, result); err != nil {
+		return fmt.Errorf("doRequest : %w", err)
+	}
+
+	return nil
+}
+
+func bingxSymbol(symbol, baseSymbol string) string {
+	return fmt.Sprintf("%s-%s", symbol, baseSymbol)
+}
+
+func newOrderFromBingx(orderRecv *orderBingx) (*commonTypes.Order, error) {
+	status, ok := bingxOrderStatus[orderRecv.Status]
+	if !ok {
+		return nil, ErrBingxOrderStatusNotFound
+	}
+
+	side, ok := bingxOrderSide[orderRecv.Side]
+	if !ok {
+		return nil, ErrBingxOrderSideNotFound
+	}
+
+	var orderType commonTypes.OrderType
+	for t, bingxType := range bingxOrderType {
+		if bingxType[0] == orderRecv.Type && bingxType[1] == "" {
+			orderType = t
+			break
+		}
+	}
+	if orderType == "" {
+		return nil, ErrBingxOrderTypeNotFound
+	}
+
+	values, err := parseFloats(orderRecv.Quantity, orderRecv.Price, orderRecv.ExecutedQty, orderRecv.AvgPrice, orderRecv.Commission)
+	if err != nil {
+		return nil, err
+	}
+
+	return &commonTypes.Order{
+		OrderID:          orderRecv.OrderID.String(),
+		ClientOrderID:    orderRecv.ClientOrderID,
+		Currency:         orderRecv.Symbol,
+		Side:             side,
+		Type:             orderType,
+		Quantity:         values[0],
+		Price:            values[1],
+		Status:           status,
+		ExecutedQuantity: values[2],
+		AveragePrice:     values[3],
+		// bingx reports paid commission as negative amount
+		Fee: math.Abs(values[4]),
+	}, nil
+}
@@ -0,0 +1,236 @@
+package client_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+const (
+	bingxTestKey    string = "key"
+	bingxTestSecret string = "secret"
+)
+
+func newBingxTestServer(t *testing.T, handlers map[string]func(t *testing.T, r *http.Request) string) *client.Bingx {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			if key != "signature" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		params := make([]string, 0, len(keys))
+		for _, key := range keys {
+			params = append(params, key+"="+query.Get(key))
+		}
+
+		mac := hmac.New(sha256.New, []byte(bingxTestSecret))
+		mac.Write([]byte(strings.Join(params, "&")))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), query.Get("signature"))
+		assert.Equal(t, bingxTestKey, r.Header.Get("X-BX-APIKEY"))
+		assert.NotEmpty(t, query.Get("timestamp"))
+
+		handler, ok := handlers[r.Method+" "+r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(handler(t, r)))
+	}))
+	t.Cleanup(server.Close)
+
+	return client.NewBingx(&client.BingxOptions{
+		ApiKey:     bingxTestKey,
+		ApiSecret:  bingxTestSecret,
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+	})
+}
+
+func TestBingxGetPrice(t *testing.T) {
+	bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+		"GET /openApi/swap/v2/quote/price": func(t *testing.T, r *http.Request) string {
+			assert.Equal(t, "SOL-USDT", r.URL.Query().Get("symbol"))
+
+			return `{"code":0,"msg":"","data":{"symbol":"SOL-USDT","price":"181.42","time":1700000000000}}`
+		},
+	})
+
+	price, err := bingx.GetPrice(context.Background(), "SOL", "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, 181.42, price)
+}
+
+func TestBingxCreateFuturesOrder(t *testing.T) {
+	bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+		"POST /openApi/swap/v2/trade/order": func(t *testing.T, r *http.Request) string {
+			query := r.URL.Query()
+			assert.Equal(t, "SOL-USDT", query.Get("symbol"))
+			assert.Equal(t, "BUY", query.Get("side"))
+			assert.Equal(t, "LONG", query.Get("positionSide"))
+			assert.Equal(t, "LIMIT", query.Get("type"))
+			assert.Equal(t, "0.5", query.Get("quantity"))
+			assert.Equal(t, "178.7", query.Get("price"))
+			assert.JSONEq(t, `{"type":"TAKE_PROFIT_MARKET","stopPrice":183.23,"price":183.23,"workingType":"MARK_PRICE"}`, query.Get("takeProfit"))
+			assert.JSONEq(t, `{"type":"STOP_MARKET","stopPrice":170.53,"price":170.53,"workingType":"MARK_PRICE"}`, query.Get("stopLoss"))
+
+			return `{"code":0,"msg":"","data":{"order":{"symbol":"SOL-USDT","orderId":1735950529123455000,"side":"BUY","positionSide":"LONG","type":"LIMIT"}}}`
+		},
+	})
+
+	orderID, err := bingx.CreateFuturesOrder(context.Background(), &types.FuturesOrder{
+		Type:       commonTypes.OrderTypeLimit,
+		Position:   commonTypes.PositionLong,
+		Symbol:     "SOL",
+		BaseSymbol: "USDT",
+		Entry:      178.7,
+		Quantity:   0.5,
+		StopLoss:   170.53,
+		TakeProfit: 183.23,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, commonTypes.OrderID("1735950529123455000"), orderID)
+}
+
+func TestBingxCloseFuturesOrder(t *testing.T) {
+	bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+		"POST /openApi/swap/v2/trade/order": func(t *testing.T, r *http.Request) string {
+			query := r.URL.Query()
+			assert.Equal(t, "SELL", query.Get("side"))
+			assert.Equal(t, "LONG", query.Get("positionSide"))
+			assert.Equal(t, "MARKET", query.Get("type"))
+			assert.Empty(t, query.Get("price"))
+
+			return `{"code":0,"msg":"","data":{"order":{"orderId":1}}}`
+		},
+	})
+
+	_, err := bingx.CreateFuturesOrder(context.Background(), &types.FuturesOrder{
+		Type:       commonTypes.OrderTypeMarket,
+		Position:   commonTypes.PositionLong,
+		Symbol:     "SOL",
+		BaseSymbol: "USDT",
+		Quantity:   0.5,
+		ReduceOnly: true,
+	})
+	assert.NoError(t, err)
+}
+
+func TestBingxSetLeverage(t *testing.T) {
+	var sides []string
+	bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+		"POST /openApi/swap/v2/trade/leverage": func(t *testing.T, r *http.Request) string {
+			assert.Equal(t, "25", r.URL.Query().Get("leverage"))
+			sides = append(sides, r.URL.Query().Get("side"))
+
+			return `{"code":0,"msg":"","data":{"leverage":25,"symbol":"SOL-USDT"}}`
+		},
+	})
+
+	assert.NoError(t, bingx.SetLeverage(context.Background(), "SOL", "USDT", 25))
+	assert.Equal(t, []string{"LONG", "SHORT"}, sides)
+}
+
+func TestBingxGetOrder(t *testing.T) {
+	bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+		"GET /openApi/swap/v2/trade/order": func(t *testing.T, r *http.Request) string {
+			assert.Equal(t, "42", r.URL.Query().Get("orderId"))
+
+			return `{"code":0,"msg":"","data":{"order":{"symbol":"SOL-USDT","orderId":42,"side":"SELL","positionSide":"SHORT","type":"LIMIT","origQty":"0.5","price":"178.7","executedQty":"0.5","avgPrice":"178.7","commission":"-0.04","status":"FILLED"}}}`
+		},
+	})
+
+	order, err := bingx.GetOrder(context.Background(), "SOL", "USDT", "42")
+	require.NoError(t, err)
+	assert.Equal(t, &commonTypes.Order{
+		OrderID:          "42",
+		Currency:         "SOL-USDT",
+		Side:             commonTypes.OrderSideShort,
+		Type:             commonTypes.OrderTypeLimit,
+		Quantity:         0.5,
+		Price:            178.7,
+		Status:           commonTypes.OrderStatusFilled,
+		ExecutedQuantity: 0.5,
+		AveragePrice:     178.7,
+		Fee:              0.04,
+	}, order)
+}
+
+func TestBingxGetPositions(t *testing.T) {
+	bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+		"GET /openApi/swap/v2/user/positions": func(t *testing.T, r *http.Request) string {
+			return `{"code":0,"msg":"","data":[{"symbol":"SOL-USDT","positionSide":"SHORT","positionAmt":"-0.5","avgPrice":"178.7","leverage":25,"liquidationPrice":185.1,"unrealizedProfit":"-0.2"}]}`
+		},
+	})
+
+	positions, err := bingx.GetPositions(context.Background(), "SOL", "USDT")
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, &types.OpenPosition{
+		Symbol:           "SOL",
+		BaseSymbol:       "USDT",
+		Position:         commonTypes.PositionShort,
+		Quantity:         0.5,
+		Entry:            178.7,
+		Leverage:         25,
+		LiquidationPrice: 185.1,
+		UnrealisedPnl:    -0.2,
+	}, positions[0])
+}
+
+func TestBingxApiError(t *testing.T) {
+	bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+		"GET /openApi/swap/v2/user/balance": func(t *testing.T, r *http.Request) string {
+			return `{"code":100001,"msg":"signature verification failed"}`
+		},
+	})
+
+	_, err := bingx.GetAssets(context.Background(), "USDT")
+	assert.ErrorContains(t, err, "100001")
+}
+
+func TestBingxErrorCategory(t *testing.T) {
+	tests := []struct {
+		code     int
+		category client.ErrorCategory
+	}{
+		{100001, client.ErrorCategoryAuth},
+		{101204, client.ErrorCategoryBalance},
+		{101415, client.ErrorCategorySymbol},
+		{100410, client.ErrorCategoryRateLimit},
+		{100421, client.ErrorCategoryTimestamp},
+		{80016, client.ErrorCategoryUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.code), func(t *testing.T) {
+			bingx := newBingxTestServer(t, map[string]func(t *testing.T, r *http.Request) string{
+				"GET /openApi/swap/v2/user/balance": func(t *testing.T, r *http.Request) string {
+					return fmt.Sprintf(`{"code":%d,"msg":"error"}`, test.code)
+				},
+			})
+
+			_, err := bingx.GetAssets(context.Background(), "USDT")
+			assert.Equal(t, test.category, client.CategoryOf(err))
+		})
+	}
+}
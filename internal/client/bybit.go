@@ -0,0 +1,799 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+const (
+	bybitBaseURL           string        = "https://api.bybit.com"
+	bybitCategoryLinear    string        = "linear"
+	bybitAccountUnified    string        = "UNIFIED"
+	bybitDefaultRecvWindow time.Duration = 5 * time.Second
+	bybitDefaultTimeout    time.Duration = 10 * time.Second
+
+	bybitCodeLeverageNotModified int = 110043
+)
+
+type bybitResponse struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type bybitList[T any] struct {
+	List []T `json:"list"`
+}
+
+type tickerBybit struct {
+	Symbol    string `json:"symbol"`
+	LastPrice string `json:"lastPrice"`
+}
+
+type coinBybit struct {
+	Coin                string `json:"coin"`
+	WalletBalance       string `json:"walletBalance"`
+	AvailableToWithdraw string `json:"availableToWithdraw"`
+	Locked              string `json:"locked"`
+}
+
+type walletBybit struct {
+	Coins []coinBybit `json:"coin"`
+}
+
+type orderCreateBybit struct {
+	Category    string `json:"category"`
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`
+	OrderType   string `json:"orderType"`
+	Qty         string `json:"qty"`
+	Price       string `json:"price,omitempty"`
+	TimeInForce string `json:"timeInForce,omitempty"`
+	TakeProfit  string `json:"takeProfit,omitempty"`
+	StopLoss    string `json:"stopLoss,omitempty"`
+	ReduceOnly  bool   `json:"reduceOnly,omitempty"`
+	OrderLinkID string `json:"orderLinkId,omitempty"`
+}
+
+type orderCreatedBybit struct {
+	OrderID     string `json:"orderId"`
+	OrderLinkID string `json:"orderLinkId"`
+}
+
+type orderBybit struct {
+	OrderID     string `json:"orderId"`
+	OrderLinkID string `json:"orderLinkId"`
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`
+	OrderType   string `json:"orderType"`
+	TimeInForce string `json:"timeInForce"`
+	Price       string `json:"price"`
+	Qty         string `json:"qty"`
+	CumExecQty  string `json:"cumExecQty"`
+	AvgPrice    string `json:"avgPrice"`
+	CumExecFee  string `json:"cumExecFee"`
+	OrderStatus string `json:"orderStatus"`
+}
+
+type orderCancelBybit struct {
+	Category string `json:"category"`
+	Symbol   string `json:"symbol"`
+	OrderID  string `json:"orderId,omitempty"`
+}
+
+type leverageBybit struct {
+	Category     string `json:"category"`
+	Symbol       string `json:"symbol"`
+	BuyLeverage  string `json:"buyLeverage"`
+	SellLeverage string `json:"sellLeverage"`
+}
+
+type tradingStopBybit struct {
+	Category    string `json:"category"`
+	Symbol      string `json:"symbol"`
+	TpslMode    string `json:"tpslMode"`
+	PositionIdx int    `json:"positionIdx"`
+	StopLoss    string `json:"stopLoss"`
+}
+
+type positionBybit struct {
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	Size          string `json:"size"`
+	AvgPrice      string `json:"avgPrice"`
+	Leverage      string `json:"leverage"`
+	LiqPrice      string `json:"liqPrice"`
+	UnrealisedPnl string `json:"unrealisedPnl"`
+}
+
+type instrumentBybit struct {
+	Symbol      string `json:"symbol"`
+	BaseCoin    string `json:"baseCoin"`
+	QuoteCoin   string `json:"quoteCoin"`
+	PriceFilter struct {
+		TickSize string `json:"tickSize"`
+	} `json:"priceFilter"`
+	LotSizeFilter struct {
+		QtyStep          string `json:"qtyStep"`
+		MinOrderQty      string `json:"minOrderQty"`
+		MinNotionalValue string `json:"minNotionalValue"`
+	} `json:"lotSizeFilter"`
+	LeverageFilter struct {
+		MaxLeverage string `json:"maxLeverage"`
+	} `json:"leverageFilter"`
+}
+
+type riskLimitBybit struct {
+	RiskLimitValue    string `json:"riskLimitValue"`
+	MaintenanceMargin string `json:"maintenanceMargin"`
+	MmDeduction       string `json:"mmDeduction"`
+}
+
+// bybitError is an error returned by Bybit API in retCode and retMsg
+type bybitError struct {
+	Code    int
+	Message string
+}
+
+func (e *bybitError) Error() string {
+	return fmt.Sprintf("bybit error %d (%s): %s", e.Code, e.Category(), e.Message)
+}
+
+// Category groups Bybit error codes
+func (e *bybitError) Category() ErrorCategory {
+	if category, ok := bybitErrorCategory[e.Code]; ok {
+		return category
+	}
+
+	return ErrorCategoryUnknown
+}
+
+var (
+	_ FuturesClient     = (*Bybit)(nil)
+	_ CandleClient      = (*Bybit)(nil)
+	_ OrderLookupClient = (*Bybit)(nil)
+	_ MarginTierClient  = (*Bybit)(nil)
+	_ StopLossClient    = (*Bybit)(nil)
+)
+
+// BybitOptions holds configuration options for the Bybit client.
+type BybitOptions struct {
+	ApiKey     string
+	ApiSecret  string
+	BaseURL    string
+	RecvWindow time.Duration
+	HTTPClient *http.Client
+}
+
+// Bybit is a client for Bybit v5 unified account trading linear perpetual contracts.
+type Bybit struct {
+	apiKey     string
+	secretKey  string
+	baseUrl    string
+	recvWindow time.Duration
+	httpClient *http.Client
+}
+
+var (
+	bybitOrderSide = map[commonTypes.Position]string{
+		commonTypes.PositionLong:  "Buy",
+		commonTypes.PositionShort: "Sell",
+	}
+
+	bybitCandleInterval = map[commonTypes.CandleInterval]string{
+		commonTypes.CandleInterval1m:  "1",
+		commonTypes.CandleInterval5m:  "5",
+		commonTypes.CandleInterval15m: "15",
+		commonTypes.CandleInterval30m: "30",
+		commonTypes.CandleInterval1h:  "60",
+		commonTypes.CandleInterval4h:  "240",
+		commonTypes.CandleInterval1d:  "D",
+		commonTypes.CandleInterval1W:  "W",
+		commonTypes.CandleInterval1M:  "M",
+	}
+
+	bybitPosition = map[string]commonTypes.Position{
+		"Buy":  commonTypes.PositionLong,
+		"Sell": commonTypes.PositionShort,
+	}
+
+	bybitOrderSideRecv = map[string]commonTypes.OrderSide{
+		"Buy":  commonTypes.OrderSideLong,
+		"Sell": commonTypes.OrderSideShort,
+	}
+
+	// bybitOrderType maps order type to bybit order type and time in force
+	bybitOrderType = map[commonTypes.OrderType][2]string{
+		commonTypes.OrderTypeLimit:             {"Limit", "GTC"},
+		commonTypes.OrderTypeMarket:            {"Market", ""},
+		commonTypes.OrderTypeImmediateOrCancel: {"Limit", "IOC"},
+		commonTypes.OrderTypeFillOrKill:        {"Limit", "FOK"},
+	}
+
+	bybitOrderStatus = map[string]commonTypes.OrderStatus{
+		"Created":                 commonTypes.OrderStatusNew,
+		"New":                     commonTypes.OrderStatusNew,
+		"Untriggered":             commonTypes.OrderStatusNew,
+		"Triggered":               commonTypes.OrderStatusNew,
+		"PartiallyFilled":         commonTypes.OrderStatusPartiallyFilled,
+		"Filled":                  commonTypes.OrderStatusFilled,
+		"Cancelled":               commonTypes.OrderStatusCanceled,
+		"Rejected":                commonTypes.OrderStatusCanceled,
+		"Deactivated":             commonTypes.OrderStatusCanceled,
+		"PartiallyFilledCanceled": commonTypes.OrderStatusPartiallyCanceled,
+	}
+
+	bybitErrorCategory = map[int]ErrorCategory{
+		10003:  ErrorCategoryAuth,      // api key invalid
+		10004:  ErrorCategoryAuth,      // signature error
+		10005:  ErrorCategoryAuth,      // permission denied
+		10010:  ErrorCategoryAuth,      // unmatched ip
+		110004: ErrorCategoryBalance,   // wallet balance insufficient
+		110007: ErrorCategoryBalance,   // available balance insufficient
+		110012: ErrorCategoryBalance,   // insufficient available balance
+		110045: ErrorCategoryBalance,   // wallet balance insufficient
+		110074: ErrorCategorySymbol,    // contract is not live
+		170121: ErrorCategorySymbol,    // invalid symbol
+		10006:  ErrorCategoryRateLimit, // too many visits
+		10018:  ErrorCategoryRateLimit, // ip rate limit exceeded
+		10002:  ErrorCategoryTimestamp, // request time outside of recv window
+	}
+)
+
+var (
+	ErrBybitOrderSideNotFound   = errors.New("bybit order side not found")
+	ErrBybitOrderTypeNotFound   = errors.New("bybit order type not found")
+	ErrBybitOrderStatusNotFound = errors.New("bybit order status not found")
+	ErrBybitSymbolNotFound      = errors.New("bybit symbol not found")
+	ErrBybitIntervalNotFound    = errors.New("bybit interval not found")
+)
+
+// NewBybit creates a new Bybit client. Empty options are replaced with defaults.
+func NewBybit(opt *BybitOptions) *Bybit {
+	baseUrl := opt.BaseURL
+	if baseUrl == "" {
+		baseUrl = bybitBaseURL
+	}
+
+	recvWindow := opt.RecvWindow
+	if recvWindow == 0 {
+		recvWindow = bybitDefaultRecvWindow
+	}
+
+	httpClient := opt.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: bybitDefaultTimeout}
+	}
+
+	return &Bybit{
+		apiKey:     opt.ApiKey,
+		secretKey:  opt.ApiSecret,
+		baseUrl:    baseUrl,
+		recvWindow: recvWindow,
+		httpClient: httpClient,
+	}
+}
+
+func (b *Bybit) Name() commonTypes.Exchange {
+	return commonTypes.ExchangeBybit
+}
+
+func (b *Bybit) GetPrice(ctx context.Context, symbol, baseSymbol string) (float64, error) {
+	queryParams := url.Values{}
+	queryParams.Set("category", bybitCategoryLinear)
+	queryParams.Set("symbol", symbol+baseSymbol)
+
+	var tickers bybitList[tickerBybit]
+	if err := b.doRequest(ctx, http.MethodGet, "/v5/market/tickers", queryParams, nil, &tickers); err != nil {
+		return 0, fmt.Errorf("Bybit::GetPrice : %w", err)
+	}
+	if len(tickers.List) == 0 {
+		return 0, ErrBybitSymbolNotFound
+	}
+
+	price, err := strconv.ParseFloat(tickers.List[0].LastPrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse price to float: %w", err)
+	}
+
+	return price, nil
+}
+
+func (b *Bybit) GetAssets(ctx context.Context, symbol string) (float64, error) {
+	queryParams := url.Values{}
+	queryParams.Set("accountType", bybitAccountUnified)
+	queryParams.Set("coin", symbol)
+
+	var wallets bybitList[walletBybit]
+	if err := b.doRequest(ctx, http.MethodGet, "/v5/account/wallet-balance", queryParams, nil, &wallets); err != nil {
+		return 0, fmt.Errorf("Bybit::GetAssets : %w", err)
+	}
+
+	for _, wallet := range wallets.List {
+		for _, coin := range wallet.Coins {
+			if coin.Coin != symbol {
+				continue
+			}
+
+			free := coin.AvailableToWithdraw
+			if free == "" {
+				free = coin.WalletBalance
+			}
+			floatValue, err := strconv.ParseFloat(free, 64)
+			if err != nil {
+				return 0, fmt.Errorf("Bybit::GetAssets : %w", err)
+			}
+
+			return floatValue, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func (b *Bybit) SetLeverage(ctx context.Context, symbol, baseSymbol string, leverage float64) error {
+	leverageValue := strconv.FormatFloat(leverage, 'f', -1, 64)
+
+	err := b.doRequest(ctx, http.MethodPost, "/v5/position/set-leverage", nil, &leverageBybit{
+		Category:     bybitCategoryLinear,
+		Symbol:       symbol + baseSymbol,
+		BuyLeverage:  leverageValue,
+		SellLeverage: leverageValue,
+	}, nil)
+
+	var apiErr *bybitError
+	if errors.As(err, &apiErr) && apiErr.Code == bybitCodeLeverageNotModified {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Bybit::SetLeverage : %w", err)
+	}
+
+	return nil
+}
+
+// SetStopLoss moves stop loss of the whole position, position is held in one-way mode
+func (b *Bybit) SetStopLoss(ctx context.Context, symbol, baseSymbol string, stop float64) error {
+	if err := b.doRequest(ctx, http.MethodPost, "/v5/position/trading-stop", nil, &tradingStopBybit{
+		Category: bybitCategoryLinear,
+		Symbol:   symbol + baseSymbol,
+		TpslMode: "Full",
+		StopLoss: strconv.FormatFloat(stop, 'f', -1, 64),
+	}, nil); err != nil {
+		return fmt.Errorf("Bybit::SetStopLoss : %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bybit) CreateFuturesOrder(ctx context.Context, order *types.FuturesOrder) (commonTypes.OrderID, error) {
+	// reduce only order closes position by the opposite side
+	position := order.Position
+	if order.ReduceOnly {
+		position = oppositePosition(position)
+	}
+	side, ok := bybitOrderSide[position]
+	if !ok {
+		return "", ErrBybitOrderSideNotFound
+	}
+
+	orderType, ok := bybitOrderType[order.Type]
+	if !ok {
+		return "", ErrBybitOrderTypeNotFound
+	}
+
+	request := &orderCreateBybit{
+		Category:    bybitCategoryLinear,
+		Symbol:      order.Symbol + order.BaseSymbol,
+		Side:        side,
+		OrderType:   orderType[0],
+		Qty:         strconv.FormatFloat(order.Quantity, 'f', -1, 64),
+		TimeInForce: orderType[1],
+		ReduceOnly:  order.ReduceOnly,
+		OrderLinkID: order.ClientOrderID,
+	}
+	if order.Type != commonTypes.OrderTypeMarket {
+		request.Price = strconv.FormatFloat(order.Entry, 'f', -1, 64)
+	}
+	if order.TakeProfit > 0 {
+		request.TakeProfit = strconv.FormatFloat(order.TakeProfit, 'f', -1, 64)
+	}
+	if order.StopLoss > 0 {
+		request.StopLoss = strconv.FormatFloat(order.StopLoss, 'f', -1, 64)
+	}
+
+	var created orderCreatedBybit
+	if err := b.doRequest(ctx, http.MethodPost, "/v5/order/create", nil, request, &created); err != nil {
+		return "", fmt.Errorf("Bybit::CreateFuturesOrder : %w", err)
+	}
+
+	return commonTypes.OrderID(created.OrderID), nil
+}
+
+func (b *Bybit) GetOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) (*commonTypes.Order, error) {
+	queryParams := url.Values{}
+	queryParams.Set("category", bybitCategoryLinear)
+	queryParams.Set("symbol", symbol+baseSymbol)
+	queryParams.Set("orderId", string(orderID))
+
+	order, err := b.findOrder(ctx, queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("Bybit::GetOrder : %w", err)
+	}
+
+	return order, nil
+}
+
+// GetOrderByClientID returns order placed with order link id, ErrOrderNotFound when there is none
+func (b *Bybit) GetOrderByClientID(ctx context.Context, symbol, baseSymbol, clientOrderID string) (*commonTypes.Order, error) {
+	queryParams := url.Values{}
+	queryParams.Set("category", bybitCategoryLinear)
+	queryParams.Set("symbol", symbol+baseSymbol)
+	queryParams.Set("orderLinkId", clientOrderID)
+
+	order, err := b.findOrder(ctx, queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("Bybit::GetOrderByClientID : %w", err)
+	}
+
+	return order, nil
+}
+
+// findOrder looks order up among open and recently closed orders first, orders that were
+// closed a while ago are only in order history. ErrOrderNotFound is returned when neither has it.
+func (b *Bybit) findOrder(ctx context.Context, queryParams url.Values) (*commonTypes.Order, error) {
+	for _, path := range []string{"/v5/order/realtime", "/v5/order/history"} {
+		var orders bybitList[orderBybit]
+		if err := b.doRequest(ctx, http.MethodGet, path, queryParams, nil, &orders); err != nil {
+			return nil, err
+		}
+		if len(orders.List) == 0 {
+			continue
+		}
+
+		return newOrderFromBybit(&orders.List[0])
+	}
+
+	return nil, ErrOrderNotFound
+}
+
+func (b *Bybit) CancelOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) error {
+	if err := b.doRequest(ctx, http.MethodPost, "/v5/order/cancel", nil, &orderCancelBybit{
+		Category: bybitCategoryLinear,
+		Symbol:   symbol + baseSymbol,
+		OrderID:  string(orderID),
+	}, nil); err != nil {
+		return fmt.Errorf("Bybit::CancelOrder : %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bybit) CancelAllOrders(ctx context.Context, symbol, baseSymbol string) error {
+	if err := b.doRequest(ctx, http.MethodPost, "/v5/order/cancel-all", nil, &orderCancelBybit{
+		Category: bybitCategoryLinear,
+		Symbol:   symbol + baseSymbol,
+	}, nil); err != nil {
+		return fmt.Errorf("Bybit::CancelAllOrders : %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bybit) GetPositions(ctx context.Context, symbol, baseSymbol string) ([]*types.OpenPosition, error) {
+	queryParams := url.Values{}
+	queryParams.Set("category", bybitCategoryLinear)
+	queryParams.Set("symbol", symbol+baseSymbol)
+
+	var positions bybitList[positionBybit]
+	if err := b.doRequest(ctx, http.MethodGet, "/v5/position/list", queryParams, nil, &positions); err != nil {
+		return nil, fmt.Errorf("Bybit::GetPositions : %w", err)
+	}
+
+	result := make([]*types.OpenPosition, 0, len(positions.List))
+	for _, p := range positions.List {
+		// bybit returns empty side for symbols without open position
+		position, ok := bybitPosition[p.Side]
+		if !ok {
+			continue
+		}
+
+		values, err := parseFloats(p.Size, p.AvgPrice, p.Leverage, p.LiqPrice, p.UnrealisedPnl)
+		if err != nil {
+			return nil, fmt.Errorf("Bybit::GetPositions : %w", err)
+		}
+
+		result = append(result, &types.OpenPosition{
+			Symbol:           symbol,
+			BaseSymbol:       baseSymbol,
+			Position:         position,
+			Quantity:         values[0],
+			Entry:            values[1],
+			Leverage:         values[2],
+			LiquidationPrice: values[3],
+			UnrealisedPnl:    values[4],
+		})
+	}
+
+	return result, nil
+}
+
+func (b *Bybit) GetSymbolInfo(ctx context.Context, symbol, baseSymbol string) (*types.SymbolInfo, error) {
+	queryParams := url.Values{}
+	queryParams.Set("category", bybitCategoryLinear)
+	queryParams.Set("symbol", symbol+baseSymbol)
+
+	var instruments bybitList[instrumentBybit]
+	if err := b.doRequest(ctx, http.MethodGet, "/v5/market/instruments-info", queryParams, nil, &instruments); err != nil {
+		return nil, fmt.Errorf("Bybit::GetSymbolInfo : %w", err)
+	}
+	if len(instruments.List) == 0 {
+		return nil, ErrBybitSymbolNotFound
+	}
+
+	instrument := instruments.List[0]
+	values, err := parseFloats(
+		instrument.PriceFilter.TickSize,
+		instrument.LotSizeFilter.QtyStep,
+		instrument.LotSizeFilter.MinOrderQty,
+		instrument.LotSizeFilter.MinNotionalValue,
+		instrument.LeverageFilter.MaxLeverage,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Bybit::GetSymbolInfo : %w", err)
+	}
+
+	return &types.SymbolInfo{
+		Symbol:      instrument.BaseCoin,
+		BaseSymbol:  instrument.QuoteCoin,
+		TickSize:    values[0],
+		StepSize:    values[1],
+		MinQuantity: values[2],
+		MinNotional: values[3],
+		MaxLeverage: values[4],
+	}, nil
+}
+
+// GetMarginTiers returns risk limits of symbol as maintenance margin tiers
+func (b *Bybit) GetMarginTiers(ctx context.Context, symbol, baseSymbol string) ([]*types.MarginTier, error) {
+	queryParams := url.Values{}
+	queryParams.Set("category", bybitCategoryLinear)
+	queryParams.Set("symbol", symbol+baseSymbol)
+
+	var riskLimits bybitList[riskLimitBybit]
+	if err := b.doRequest(ctx, http.MethodGet, "/v5/market/risk-limit", queryParams, nil, &riskLimits); err != nil {
+		return nil, fmt.Errorf("Bybit::GetMarginTiers : %w", err)
+	}
+	if len(riskLimits.List) == 0 {
+		return nil, ErrBybitSymbolNotFound
+	}
+
+	tiers := make([]*types.MarginTier, 0, len(riskLimits.List))
+	for _, riskLimit := range riskLimits.List {
+		// deduction is empty for the lowest tier
+		deduction := riskLimit.MmDeduction
+		if deduction == "" {
+			deduction = "0"
+		}
+
+		values, err := parseFloats(riskLimit.RiskLimitValue, riskLimit.MaintenanceMargin, deduction)
+		if err != nil {
+			return nil, fmt.Errorf("Bybit::GetMarginTiers : %w", err)
+		}
+
+		tiers = append(tiers, &types.MarginTier{
+			MaxValue:             values[0],
+			MaintenanceRate:      values[1],
+			MaintenanceDeduction: values[2],
+		})
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MaxValue < tiers[j].MaxValue
+	})
+
+	return tiers, nil
+}
+
+// GetCandles returns the latest candles from the oldest to the newest
+func (b *Bybit) GetCandles(ctx context.Context, symbol, baseSymbol string, interval commonTypes.CandleInterval, limit int) ([]*commonTypes.Candle, error) {
+	candleInterval, ok := bybitCandleInterval[interval]
+	if !ok {
+		return nil, ErrBybitIntervalNotFound
+	}
+
+	queryParams := url.Values{}
+	queryParams.Set("category", bybitCategoryLinear)
+	queryParams.Set("symbol", symbol+baseSymbol)
+	queryParams.Set("interval", candleInterval)
+	if limit > 0 {
+		queryParams.Set("limit", strconv.Itoa(limit))
+	}
+
+	// every kline is [startTime, open, high, low, close, volume, turnover], the newest goes first
+	var klines bybitList[[]string]
+	if err := b.doRequest(ctx, http.MethodGet, "/v5/market/kline", queryParams, nil, &klines); err != nil {
+		return nil, fmt.Errorf("Bybit::GetCandles : %w", err)
+	}
+
+	candles := make([]*commonTypes.Candle, len(klines.List))
+	for i, kline := range klines.List {
+		if len(kline) < 7 {
+			return nil, fmt.Errorf("Bybit::GetCandles : kline has %d fields", len(kline))
+		}
+
+		values, err := parseFloats(kline...)
+		if err != nil {
+			return nil, fmt.Errorf("Bybit::GetCandles : %w", err)
+		}
+
+		candles[len(candles)-1-i] = &commonTypes.Candle{
+			OpenTime:    time.UnixMilli(int64(values[0])),
+			Open:        values[1],
+			High:        values[2],
+			Low:         values[3],
+			Close:       values[4],
+			Volume:      values[5],
+			AssetVolume: values[6],
+			Interval:    interval,
+		}
+	}
+
+	return candles, nil
+}
+
+// doRequest signs and sends request. GET parameters are passed in query, POST parameters are sent as JSON body.
+func (b *Bybit) doRequest(ctx context.Context, method, path string, queryParams url.Values, body any, result any) error {
+	var (
+		payload     string
+		requestBody io.Reader
+	)
+
+	requestURL := b.baseUrl + path
+	if len(queryParams) > 0 {
+		payload = queryParams.Encode()
+		requestURL = fmt.Sprintf("%s?%s", requestURL, payload)
+	}
+	if body != nil {
+		rawBody, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("doRequest : %w", err)
+		}
+		payload = string(rawBody)
+		requestBody = bytes.NewReader(rawBody)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	recvWindow := strconv.FormatInt(b.recvWindow.Milliseconds(), 10)
+
+	mac := hmac.New(sha256.New, []byte(b.secretKey))
+	mac.Write([]byte(timestamp + b.apiKey + recvWindow + payload))
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, requestBody)
+	if err != nil {
+		return fmt.Errorf("doRequest : %w", err)
+	}
+	req.Header.Set("X-BAPI-API-KEY", b.apiKey)
+	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+	req.Header.Set("X-BAPI-RECV-WINDOW", recvWindow)
+	req.Header.Set("X-BAPI-SIGN", hex.EncodeToString(mac.Sum(nil)))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response : %w", err)
+	}
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 600 {
+		return fmt.Errorf("received error status %d with message: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response bybitResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return fmt.Errorf("doRequest : %w", err)
+	}
+	if response.RetCode != 0 {
+		return &bybitError{Code: response.RetCode, Message: response.RetMsg}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("doRequest : %w", err)
+	}
+
+	return nil
+}
+
+func newOrderFromBybit(orderRecv *orderBybit) (*commonTypes.Order, error) {
+	status, ok := bybitOrderStatus[orderRecv.OrderStatus]
+	if !ok {
+		return nil, ErrBybitOrderStatusNotFound
+	}
+
+	side, ok := bybitOrderSideRecv[orderRecv.Side]
+	if !ok {
+		return nil, ErrBybitOrderSideNotFound
+	}
+
+	var orderType commonTypes.OrderType
+	for t, bybitType := range bybitOrderType {
+		if bybitType[0] == orderRecv.OrderType && (bybitType[1] == "" || bybitType[1] == orderRecv.TimeInForce) {
+			orderType = t
+			break
+		}
+	}
+	if orderType == "" {
+		return nil, ErrBybitOrderTypeNotFound
+	}
+
+	values, err := parseFloats(orderRecv.Qty, orderRecv.Price, orderRecv.CumExecQty, orderRecv.AvgPrice, orderRecv.CumExecFee)
+	if err != nil {
+		return nil, err
+	}
+
+	return &commonTypes.Order{
+		OrderID:          orderRecv.OrderID,
+		ClientOrderID:    orderRecv.OrderLinkID,
+		Currency:         orderRecv.Symbol,
+		Side:             side,
+		Type:             orderType,
+		Quantity:         values[0],
+		Price:            values[1],
+		Status:           status,
+		ExecutedQuantity: values[2],
+		AveragePrice:     values[3],
+		Fee:              values[4],
+	}, nil
+}
+
+func oppositePosition(position commonTypes.Position) commonTypes.Position {
+	if position == commonTypes.PositionLong {
+		return commonTypes.PositionShort
+	}
+
+	return commonTypes.PositionLong
+}
+
+// parseFloats parses exchange decimal strings, empty strings are treated as zero
+func parseFloats(values ...string) ([]float64, error) {
+	result := make([]float64, len(values))
+
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse %q to float: %w", value, err)
+		}
+		result[i] = floatValue
+	}
+
+	return result, nil
+}
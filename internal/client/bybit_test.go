@@ -0,0 +1,344 @@
+package client_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+const (
+	bybitTestKey    string = "key"
+	bybitTestSecret string = "secret"
+)
+
+func newBybitTestServer(t *testing.T, handlers map[string]func(t *testing.T, r *http.Request, body []byte) string) *client.Bybit {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		payload := r.URL.RawQuery
+		if r.Method == http.MethodPost {
+			payload = string(body)
+		}
+		mac := hmac.New(sha256.New, []byte(bybitTestSecret))
+		mac.Write([]byte(r.Header.Get("X-BAPI-TIMESTAMP") + bybitTestKey + r.Header.Get("X-BAPI-RECV-WINDOW") + payload))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-BAPI-SIGN"))
+		assert.Equal(t, bybitTestKey, r.Header.Get("X-BAPI-API-KEY"))
+
+		handler, ok := handlers[r.Method+" "+r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(handler(t, r, body)))
+	}))
+	t.Cleanup(server.Close)
+
+	return client.NewBybit(&client.BybitOptions{
+		ApiKey:     bybitTestKey,
+		ApiSecret:  bybitTestSecret,
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+	})
+}
+
+func TestBybitGetPrice(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/market/tickers": func(t *testing.T, r *http.Request, _ []byte) string {
+			assert.Equal(t, "linear", r.URL.Query().Get("category"))
+			assert.Equal(t, "ETCUSDT", r.URL.Query().Get("symbol"))
+
+			return `{"retCode":0,"retMsg":"OK","result":{"category":"linear","list":[{"symbol":"ETCUSDT","lastPrice":"18.715"}]}}`
+		},
+	})
+
+	price, err := bybit.GetPrice(context.Background(), "ETC", "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, 18.715, price)
+}
+
+func TestBybitGetAssets(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/account/wallet-balance": func(t *testing.T, r *http.Request, _ []byte) string {
+			assert.Equal(t, "UNIFIED", r.URL.Query().Get("accountType"))
+
+			return `{"retCode":0,"retMsg":"OK","result":{"list":[{"accountType":"UNIFIED","coin":[{"coin":"USDT","walletBalance":"120.5","availableToWithdraw":"100.25","locked":"0"}]}]}}`
+		},
+	})
+
+	free, err := bybit.GetAssets(context.Background(), "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, 100.25, free)
+
+	free, err = bybit.GetAssets(context.Background(), "BTC")
+	assert.NoError(t, err)
+	assert.Zero(t, free)
+}
+
+func TestBybitCreateFuturesOrder(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"POST /v5/order/create": func(t *testing.T, r *http.Request, body []byte) string {
+			var request map[string]any
+			require.NoError(t, json.Unmarshal(body, &request))
+
+			assert.Equal(t, "linear", request["category"])
+			assert.Equal(t, "ETCUSDT", request["symbol"])
+			assert.Equal(t, "Sell", request["side"])
+			assert.Equal(t, "Limit", request["orderType"])
+			assert.Equal(t, "GTC", request["timeInForce"])
+			assert.Equal(t, "2.5", request["qty"])
+			assert.Equal(t, "18.5", request["price"])
+			assert.Equal(t, "17.902", request["takeProfit"])
+			assert.Equal(t, "18.592", request["stopLoss"])
+
+			return `{"retCode":0,"retMsg":"OK","result":{"orderId":"1321003749386327552","orderLinkId":""}}`
+		},
+	})
+
+	orderID, err := bybit.CreateFuturesOrder(context.Background(), &types.FuturesOrder{
+		Type:       commonTypes.OrderTypeLimit,
+		Position:   commonTypes.PositionShort,
+		Symbol:     "ETC",
+		BaseSymbol: "USDT",
+		Entry:      18.5,
+		Quantity:   2.5,
+		Leverage:   25,
+		StopLoss:   18.592,
+		TakeProfit: 17.902,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, commonTypes.OrderID("1321003749386327552"), orderID)
+}
+
+func TestBybitSetLeverage(t *testing.T) {
+	retCode := 0
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"POST /v5/position/set-leverage": func(t *testing.T, r *http.Request, body []byte) string {
+			assert.JSONEq(t, `{"category":"linear","symbol":"ETCUSDT","buyLeverage":"25","sellLeverage":"25"}`, string(body))
+
+			if retCode != 0 {
+				return `{"retCode":110043,"retMsg":"leverage not modified","result":{}}`
+			}
+			return `{"retCode":0,"retMsg":"OK","result":{}}`
+		},
+	})
+
+	assert.NoError(t, bybit.SetLeverage(context.Background(), "ETC", "USDT", 25))
+
+	// not modified leverage is not an error
+	retCode = 110043
+	assert.NoError(t, bybit.SetLeverage(context.Background(), "ETC", "USDT", 25))
+}
+
+func TestBybitSetStopLoss(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"POST /v5/position/trading-stop": func(t *testing.T, r *http.Request, body []byte) string {
+			assert.JSONEq(t, `{"category":"linear","symbol":"ETCUSDT","tpslMode":"Full","positionIdx":0,"stopLoss":"18.5"}`, string(body))
+
+			return `{"retCode":0,"retMsg":"OK","result":{}}`
+		},
+	})
+
+	assert.NoError(t, bybit.SetStopLoss(context.Background(), "ETC", "USDT", 18.5))
+}
+
+func TestBybitGetOrder(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/order/realtime": func(t *testing.T, r *http.Request, _ []byte) string {
+			assert.Equal(t, "42", r.URL.Query().Get("orderId"))
+
+			return `{"retCode":0,"retMsg":"OK","result":{"list":[{"orderId":"42","symbol":"ETCUSDT","side":"Buy","orderType":"Limit","timeInForce":"GTC","price":"18.5","qty":"2","cumExecQty":"1","avgPrice":"18.5","cumExecFee":"0.01","orderStatus":"PartiallyFilled"}]}}`
+		},
+	})
+
+	order, err := bybit.GetOrder(context.Background(), "ETC", "USDT", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "42", order.OrderID)
+	assert.Equal(t, commonTypes.OrderSideLong, order.Side)
+	assert.Equal(t, commonTypes.OrderTypeLimit, order.Type)
+	assert.Equal(t, commonTypes.OrderStatusPartiallyFilled, order.Status)
+	assert.Equal(t, 2.0, order.Quantity)
+	assert.Equal(t, 18.5, order.Price)
+	assert.Equal(t, 1.0, order.ExecutedQuantity)
+	assert.Equal(t, 18.5, order.AveragePrice)
+	assert.Equal(t, 0.01, order.Fee)
+}
+
+func TestBybitGetOrderFromHistory(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/order/realtime": func(t *testing.T, r *http.Request, _ []byte) string {
+			return `{"retCode":0,"retMsg":"OK","result":{"list":[]}}`
+		},
+		"GET /v5/order/history": func(t *testing.T, r *http.Request, _ []byte) string {
+			assert.Equal(t, "42", r.URL.Query().Get("orderId"))
+
+			return `{"retCode":0,"retMsg":"OK","result":{"list":[{"orderId":"42","symbol":"ETCUSDT","side":"Sell","orderType":"Market","price":"0","qty":"2","cumExecQty":"2","avgPrice":"18.5","cumExecFee":"0.02","orderStatus":"Filled"}]}}`
+		},
+	})
+
+	order, err := bybit.GetOrder(context.Background(), "ETC", "USDT", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "42", order.OrderID)
+	assert.Equal(t, commonTypes.OrderStatusFilled, order.Status)
+	assert.Equal(t, 2.0, order.ExecutedQuantity)
+}
+
+func TestBybitGetOrderNotFound(t *testing.T) {
+	requests := 0
+	emptyList := func(t *testing.T, r *http.Request, _ []byte) string {
+		requests++
+
+		return `{"retCode":0,"retMsg":"OK","result":{"list":[]}}`
+	}
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/order/realtime": emptyList,
+		"GET /v5/order/history":  emptyList,
+	})
+
+	_, err := bybit.GetOrder(context.Background(), "ETC", "USDT", "42")
+	assert.ErrorIs(t, err, client.ErrOrderNotFound)
+	assert.Equal(t, 2, requests)
+}
+
+func TestBybitGetPositions(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/position/list": func(t *testing.T, r *http.Request, _ []byte) string {
+			return `{"retCode":0,"retMsg":"OK","result":{"list":[
+				{"symbol":"ETCUSDT","side":"Buy","size":"2","avgPrice":"18.5","leverage":"25","liqPrice":"17.9","unrealisedPnl":"0.4"},
+				{"symbol":"ETCUSDT","side":"","size":"0","avgPrice":"0","leverage":"25","liqPrice":"","unrealisedPnl":"0"}
+			]}}`
+		},
+	})
+
+	positions, err := bybit.GetPositions(context.Background(), "ETC", "USDT")
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, &types.OpenPosition{
+		Symbol:           "ETC",
+		BaseSymbol:       "USDT",
+		Position:         commonTypes.PositionLong,
+		Quantity:         2,
+		Entry:            18.5,
+		Leverage:         25,
+		LiquidationPrice: 17.9,
+		UnrealisedPnl:    0.4,
+	}, positions[0])
+}
+
+func TestBybitGetSymbolInfo(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/market/instruments-info": func(t *testing.T, r *http.Request, _ []byte) string {
+			return `{"retCode":0,"retMsg":"OK","result":{"list":[{"symbol":"ETCUSDT","baseCoin":"ETC","quoteCoin":"USDT",
+				"priceFilter":{"tickSize":"0.001"},"lotSizeFilter":{"qtyStep":"0.1","minOrderQty":"0.1","minNotionalValue":"5"},
+				"leverageFilter":{"minLeverage":"1","maxLeverage":"50.00","leverageStep":"0.01"}}]}}`
+		},
+	})
+
+	info, err := bybit.GetSymbolInfo(context.Background(), "ETC", "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, &types.SymbolInfo{
+		Symbol:      "ETC",
+		BaseSymbol:  "USDT",
+		TickSize:    0.001,
+		StepSize:    0.1,
+		MinQuantity: 0.1,
+		MinNotional: 5,
+		MaxLeverage: 50,
+	}, info)
+}
+
+func TestBybitGetMarginTiers(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/market/risk-limit": func(t *testing.T, r *http.Request, _ []byte) string {
+			assert.Equal(t, "ETCUSDT", r.URL.Query().Get("symbol"))
+
+			return `{"retCode":0,"retMsg":"OK","result":{"category":"linear","list":[
+				{"id":2,"symbol":"ETCUSDT","riskLimitValue":"400000","maintenanceMargin":"0.02","mmDeduction":"1000","maxLeverage":"25.00"},
+				{"id":1,"symbol":"ETCUSDT","riskLimitValue":"200000","maintenanceMargin":"0.01","mmDeduction":"","maxLeverage":"50.00"}]}}`
+		},
+	})
+
+	tiers, err := bybit.GetMarginTiers(context.Background(), "ETC", "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, []*types.MarginTier{
+		{MaxValue: 200000, MaintenanceRate: 0.01},
+		{MaxValue: 400000, MaintenanceRate: 0.02, MaintenanceDeduction: 1000},
+	}, tiers)
+}
+
+func TestBybitApiError(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"POST /v5/order/cancel": func(t *testing.T, r *http.Request, _ []byte) string {
+			return `{"retCode":110001,"retMsg":"order not exists or too late to cancel","result":{}}`
+		},
+	})
+
+	err := bybit.CancelOrder(context.Background(), "ETC", "USDT", "42")
+	assert.ErrorContains(t, err, "110001")
+}
+
+func TestBybitErrorCategory(t *testing.T) {
+	tests := []struct {
+		code     int
+		category client.ErrorCategory
+	}{
+		{10004, client.ErrorCategoryAuth},
+		{110007, client.ErrorCategoryBalance},
+		{110074, client.ErrorCategorySymbol},
+		{10006, client.ErrorCategoryRateLimit},
+		{10002, client.ErrorCategoryTimestamp},
+		{110001, client.ErrorCategoryUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.code), func(t *testing.T) {
+			bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+				"POST /v5/order/cancel": func(t *testing.T, r *http.Request, _ []byte) string {
+					return fmt.Sprintf(`{"retCode":%d,"retMsg":"error","result":{}}`, test.code)
+				},
+			})
+
+			err := bybit.CancelOrder(context.Background(), "ETC", "USDT", "42")
+			assert.Equal(t, test.category, client.CategoryOf(err))
+		})
+	}
+}
+
+func TestBybitGetCandles(t *testing.T) {
+	bybit := newBybitTestServer(t, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /v5/market/kline": func(t *testing.T, r *http.Request, _ []byte) string {
+			assert.Equal(t, "ETCUSDT", r.URL.Query().Get("symbol"))
+			assert.Equal(t, "60", r.URL.Query().Get("interval"))
+			assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+			return `{"retCode":0,"retMsg":"OK","result":{"category":"linear","symbol":"ETCUSDT","list":[` +
+				`["1700003600000","18.8","19.1","18.7","19","1200","22800"],` +
+				`["1700000000000","18.5","18.9","18.4","18.8","1000","18700"]]}}`
+		},
+	})
+
+	candles, err := bybit.GetCandles(context.Background(), "ETC", "USDT", commonTypes.CandleInterval1h, 2)
+	require.NoError(t, err)
+	require.Len(t, candles, 2)
+
+	assert.Equal(t, int64(1700000000000), candles[0].OpenTime.UnixMilli())
+	assert.Equal(t, 18.9, candles[0].High)
+	assert.Equal(t, 19.0, candles[1].Close)
+	assert.Equal(t, commonTypes.CandleInterval1h, candles[1].Interval)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+var (
+	ErrClientNotFound = errors.New("exchange client not found")
+	ErrOrderNotFound  = errors.New("order not found on exchange")
+)
+
+// Client is a contract every exchange client implements.
+type Client interface {
+	Name() commonTypes.Exchange
+	GetPrice(ctx context.Context, symbol, baseSymbol string) (float64, error)
+	// GetAssets returns free amount of symbol, zero when account does not hold it.
+	GetAssets(ctx context.Context, symbol string) (float64, error)
+	GetOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) (*commonTypes.Order, error)
+	CancelOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) error
+	CancelAllOrders(ctx context.Context, symbol, baseSymbol string) error
+	GetSymbolInfo(ctx context.Context, symbol, baseSymbol string) (*types.SymbolInfo, error)
+}
+
+// SpotClient is an exchange client able to place spot orders.
+type SpotClient interface {
+	Client
+	CreateSpotOrder(ctx context.Context, order *types.SpotOrder) (commonTypes.OrderID, error)
+}
+
+// FuturesClient is an exchange client able to trade leveraged perpetual contracts.
+type FuturesClient interface {
+	Client
+	SetLeverage(ctx context.Context, symbol, baseSymbol string, leverage float64) error
+	CreateFuturesOrder(ctx context.Context, order *types.FuturesOrder) (commonTypes.OrderID, error)
+	GetPositions(ctx context.Context, symbol, baseSymbol string) ([]*types.OpenPosition, error)
+}
+
+// CandleClient is an exchange client able to return price history.
+type CandleClient interface {
+	Client
+	GetCandles(ctx context.Context, symbol, baseSymbol string, interval commonTypes.CandleInterval, limit int) ([]*commonTypes.Candle, error)
+}
+
+// StopLossClient is an exchange client able to move stop loss kept on exchange for open futures position.
+type StopLossClient interface {
+	FuturesClient
+	SetStopLoss(ctx context.Context, symbol, baseSymbol string, stop float64) error
+}
+
+// OrderLookupClient is an exchange client able to find order by client order id it is placed with.
+// ErrOrderNotFound is returned when exchange has no such order.
+type OrderLookupClient interface {
+	Client
+	GetOrderByClientID(ctx context.Context, symbol, baseSymbol, clientOrderID string) (*commonTypes.Order, error)
+}
+
+// MarginTierClient is an exchange client able to return maintenance margin tiers of symbol,
+// ordered by position value from the lowest tier.
+type MarginTierClient interface {
+	FuturesClient
+	GetMarginTiers(ctx context.Context, symbol, baseSymbol string) ([]*types.MarginTier, error)
+}
+
+// PortfolioClient is an exchange client able to return every asset of account.
+type PortfolioClient interface {
+	Client
+	GetPortfolio(ctx context.Context, quoteSymbol string) (*types.Portfolio, error)
+}
+
+// Registry keeps exchange clients by exchange name.
+type Registry struct {
+	mu      sync.RWMutex
+	clients map[commonTypes.Exchange]Client
+}
+
+func NewRegistry(clients ...Client) *Registry {
+	registry := &Registry{
+		clients: make(map[commonTypes.Exchange]Client, len(clients)),
+	}
+
+	for _, c := range clients {
+		registry.Register(c)
+	}
+
+	return registry
+}
+
+// Register adds client to registry replacing previously registered client of the same exchange.
+func (r *Registry) Register(c Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clients[c.Name()] = c
+}
+
+// Get returns client of exchange or ErrClientNotFound.
+func (r *Registry) Get(exchange commonTypes.Exchange) (Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.clients[exchange]
+	if !ok {
+		return nil, ErrClientNotFound
+	}
+
+	return c, nil
+}
@@ -0,0 +1,31 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	commonTypes "trade_bot/internal/types"
+)
+
+func TestRegistry(t *testing.T) {
+	mexc := client.NewMexc(&client.MexcOptions{})
+	registry := client.NewRegistry(mexc)
+
+	found, err := registry.Get(commonTypes.ExchangeMexc)
+	require.NoError(t, err)
+	assert.Same(t, mexc, found)
+
+	_, err = registry.Get(commonTypes.ExchangeBybit)
+	assert.ErrorIs(t, err, client.ErrClientNotFound)
+
+	// client registered later replaces the one of the same exchange
+	futures := client.NewMexcFutures(&client.MexcFuturesOptions{})
+	registry.Register(futures)
+
+	found, err = registry.Get(commonTypes.ExchangeMexc)
+	require.NoError(t, err)
+	assert.Same(t, futures, found)
+}
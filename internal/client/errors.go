@@ -0,0 +1,29 @@
+package client
+
+import "errors"
+
+// ErrorCategory tells what kind of failure exchange reported, so callers decide whether to retry, skip or alert
+type ErrorCategory string
+
+const (
+	ErrorCategoryAuth      ErrorCategory = "auth"
+	ErrorCategoryBalance   ErrorCategory = "balance"
+	ErrorCategorySymbol    ErrorCategory = "symbol"
+	ErrorCategoryRateLimit ErrorCategory = "rate_limit"
+	ErrorCategoryTimestamp ErrorCategory = "timestamp"
+	ErrorCategoryUnknown   ErrorCategory = "unknown"
+)
+
+type categorizedError interface {
+	Category() ErrorCategory
+}
+
+// CategoryOf returns category of exchange error in err chain, ErrorCategoryUnknown when there is none
+func CategoryOf(err error) ErrorCategory {
+	var categorized categorizedError
+	if errors.As(err, &categorized) {
+		return categorized.Category()
+	}
+
+	return ErrorCategoryUnknown
+}
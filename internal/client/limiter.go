@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// weightLimiter allows requests with total weight up to limit within every window.
+// Request exceeding the limit waits for the next window.
+type weightLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	used        int
+	windowStart time.Time
+}
+
+func newWeightLimiter(limit int, window time.Duration) *weightLimiter {
+	return &weightLimiter{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Wait blocks until request of weight may be sent or ctx is done
+func (l *weightLimiter) Wait(ctx context.Context, weight int) error {
+	for {
+		delay := l.reserve(weight)
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes weight from current window, or returns how long to wait for the next one
+func (l *weightLimiter) reserve(weight int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		l.used = 0
+	}
+
+	// request heavier than limit is let through in an empty window
+	if l.used+weight > l.limit && l.used > 0 {
+		return l.window - now.Sub(l.windowStart)
+	}
+
+	l.used += weight
+
+	return 0
+}
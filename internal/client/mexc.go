@@ -2,16 +2,15 @@ package client
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"trade_bot/internal/client/types"
@@ -21,6 +20,20 @@ import (
 const (
 	baseURL         string = "https://api.mexc.com"
 	mexcCandleLimit int    = 10
+
+	mexcDefaultTimeout    time.Duration = 10 * time.Second
+	mexcDefaultMaxRetries int           = 3
+	mexcRetryBaseDelay    time.Duration = 250 * time.Millisecond
+	// mexc allows 500 weight per 10 seconds for ip
+	mexcWeightLimit  int           = 500
+	mexcWeightWindow time.Duration = 10 * time.Second
+
+	mexcDefaultRecvWindow   time.Duration = 5 * time.Second
+	mexcDefaultTimeSyncEach time.Duration = 10 * time.Minute
+	// mexcTimeSyncRetryDelay is how long requests are signed with the last known drift after failed sync
+	mexcTimeSyncRetryDelay time.Duration = 30 * time.Second
+
+	mexcOrderNotExistCode int = -2013
 )
 
 type currencyPrice struct {
@@ -34,6 +47,41 @@ type orderCreated struct {
 	Price    string `json:"price"`
 }
 
+type orderMexc struct {
+	Currency            string `json:"symbol"`
+	OrderID             string `json:"orderId"`
+	ClientOrderID       string `json:"clientOrderId"`
+	Price               string `json:"price"`
+	Quantity            string `json:"origQty"`
+	ExecutedQuantity    string `json:"executedQty"`
+	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	Status              string `json:"status"`
+	Type                string `json:"type"`
+	Side                string `json:"side"`
+}
+
+type tradeMexc struct {
+	OrderID         string `json:"orderId"`
+	Price           string `json:"price"`
+	Quantity        string `json:"qty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+}
+
+type symbolMexc struct {
+	Symbol               string `json:"symbol"`
+	BaseAsset            string `json:"baseAsset"`
+	QuoteAsset           string `json:"quoteAsset"`
+	BaseAssetPrecision   int    `json:"baseAssetPrecision"`
+	QuotePrecision       int    `json:"quotePrecision"`
+	BaseSizePrecision    string `json:"baseSizePrecision"`
+	QuoteAmountPrecision string `json:"quoteAmountPrecision"`
+}
+
+type exchangeInfoMexc struct {
+	Symbols []symbolMexc `json:"symbols"`
+}
+
 type balanceMexc struct {
 	Currency string `json:"asset"`
 	Free     string `json:"free"`
@@ -44,10 +92,85 @@ type accountMexc struct {
 	Balances []balanceMexc `json:"balances"`
 }
 
+var (
+	_ SpotClient        = (*Mexc)(nil)
+	_ CandleClient      = (*Mexc)(nil)
+	_ OrderLookupClient = (*Mexc)(nil)
+	_ PortfolioClient   = (*Mexc)(nil)
+)
+
+type serverTimeMexc struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+type errorMexc struct {
+	Code    int    `json:"code"`
+	Message string `json:"msg"`
+}
+
+// MexcError is an error returned by MEXC spot API
+type MexcError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+func (e *MexcError) Error() string {
+	return fmt.Sprintf("mexc error %d (status %d, %s): %s", e.Code, e.StatusCode, e.Category(), e.Message)
+}
+
+// Category groups MEXC error codes, status code is used for errors without known code
+func (e *MexcError) Category() ErrorCategory {
+	if category, ok := mexcErrorCategory[e.Code]; ok {
+		return category
+	}
+
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorCategoryAuth
+	case http.StatusTooManyRequests:
+		return ErrorCategoryRateLimit
+	}
+
+	return ErrorCategoryUnknown
+}
+
+type MexcOptions struct {
+	ApiKey    string
+	ApiSecret string
+	// BaseURL points client to exchange, mock server or proxy
+	BaseURL string
+	// HTTPClient is used as is when set, otherwise client with timeout over Transport is created
+	HTTPClient *http.Client
+	Transport  http.RoundTripper
+	// Clock returns local time, time.Now is used without it
+	Clock func() time.Time
+	// Signer signs private requests, HMAC of ApiSecret is used without it
+	Signer Signer
+	// MaxRetries is how many times failed idempotent request is repeated, negative disables retries
+	MaxRetries int
+	// RecvWindow is how long after its timestamp signed request is valid for exchange
+	RecvWindow time.Duration
+	// TimeSyncInterval is how often drift of local clock from exchange clock is measured again
+	TimeSyncInterval time.Duration
+}
+
 type Mexc struct {
-	apiKey    string
-	secretKey string
-	baseUrl   string
+	apiKey     string
+	signer     Signer
+	baseUrl    string
+	httpClient *http.Client
+	maxRetries int
+	limiter    *weightLimiter
+	recvWindow time.Duration
+	now        func() time.Time
+
+	timeSyncInterval time.Duration
+	timeMu           sync.Mutex
+	timeOffset       time.Duration
+	timeSyncedAt     time.Time
+	timeSyncFailedAt time.Time
+	timeSyncing      bool
 }
 
 var (
@@ -63,55 +186,167 @@ var (
 		commonTypes.PositionLong:  "BUY",
 		commonTypes.PositionShort: "SELL",
 	}
+
+	mexcErrorCategory = map[int]ErrorCategory{
+		10072:  ErrorCategoryAuth,      // api key info invalid
+		700001: ErrorCategoryAuth,      // api key format invalid
+		700002: ErrorCategoryAuth,      // signature invalid
+		700006: ErrorCategoryAuth,      // ip not in whitelist
+		700007: ErrorCategoryAuth,      // no permission to access endpoint
+		10101:  ErrorCategoryBalance,   // insufficient balance
+		30004:  ErrorCategoryBalance,   // insufficient position
+		30005:  ErrorCategoryBalance,   // oversold
+		-1121:  ErrorCategorySymbol,    // invalid symbol
+		10007:  ErrorCategorySymbol,    // symbol not supported for api
+		30014:  ErrorCategorySymbol,    // invalid symbol
+		30021:  ErrorCategorySymbol,    // symbol trading disabled
+		510:    ErrorCategoryRateLimit, // excessive frequency of requests
+		429:    ErrorCategoryRateLimit,
+		700003: ErrorCategoryTimestamp, // timestamp outside of recvWindow
+	}
+
+	// mexcRequestWeight is weight of endpoint in ip rate limit, endpoints not listed weigh 1
+	mexcRequestWeight = map[string]int{
+		"/api/v3/order":        2,
+		"/api/v3/openOrders":   3,
+		"/api/v3/myTrades":     10,
+		"/api/v3/exchangeInfo": 10,
+		"/api/v3/account":      10,
+	}
+
+	// mexcAllSymbolsWeight is weight of endpoint queried for every symbol at once, without symbol parameter
+	mexcAllSymbolsWeight = map[string]int{
+		"/api/v3/ticker/price": 2,
+	}
+
+	mexcCandleInterval = map[commonTypes.CandleInterval]string{
+		commonTypes.CandleInterval1m:  "1m",
+		commonTypes.CandleInterval5m:  "5m",
+		commonTypes.CandleInterval15m: "15m",
+		commonTypes.CandleInterval30m: "30m",
+		commonTypes.CandleInterval1h:  "60m",
+		commonTypes.CandleInterval4h:  "4h",
+		commonTypes.CandleInterval1d:  "1d",
+		commonTypes.CandleInterval1W:  "1W",
+		commonTypes.CandleInterval1M:  "1M",
+	}
+
+	mexcOrderSide = map[string]commonTypes.OrderSide{
+		"BUY":  commonTypes.OrderSideLong,
+		"SELL": commonTypes.OrderSideShort,
+	}
+
+	mexcOrderStatus = map[string]commonTypes.OrderStatus{
+		"NEW":                commonTypes.OrderStatusNew,
+		"FILLED":             commonTypes.OrderStatusFilled,
+		"PARTIALLY_FILLED":   commonTypes.OrderStatusPartiallyFilled,
+		"CANCELED":           commonTypes.OrderStatusCanceled,
+		"PARTIALLY_CANCELED": commonTypes.OrderStatusPartiallyCanceled,
+	}
 )
 
 var (
 	ErrMexcIntervalNotFound    = errors.New("mexc interval not found")
 	ErrMexcOrderSideNotFound   = errors.New("mexc order side not found")
 	ErrMexcOrderTypeNotFound   = errors.New("mexc order type not found")
-	ErrAssetNotFound           = errors.New("asset not found")
+	ErrMexcOrderStatusNotFound = errors.New("mexc order status not found")
+	ErrMexcSymbolNotFound      = errors.New("mexc symbol not found")
 )
 
-func NewMexc(
-	apiKey, apiSecret string,
-) *Mexc {
+// NewMexc creates a new Mexc spot client. Empty options are replaced with defaults.
+func NewMexc(opt *MexcOptions) *Mexc {
+	baseUrl := opt.BaseURL
+	if baseUrl == "" {
+		baseUrl = baseURL
+	}
+
+	httpClient := opt.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   mexcDefaultTimeout,
+			Transport: opt.Transport,
+		}
+	}
+
+	clock := opt.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	var signer Signer = NewHMACSigner(opt.ApiSecret)
+	if opt.Signer != nil {
+		signer = opt.Signer
+	}
+
+	maxRetries := opt.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = mexcDefaultMaxRetries
+	}
+
+	recvWindow := opt.RecvWindow
+	if recvWindow == 0 {
+		recvWindow = mexcDefaultRecvWindow
+	}
+
+	timeSyncInterval := opt.TimeSyncInterval
+	if timeSyncInterval == 0 {
+		timeSyncInterval = mexcDefaultTimeSyncEach
+	}
+
 	return &Mexc{
-		apiKey:    apiKey,
-		secretKey: apiSecret,
-		baseUrl:   baseURL,
+		apiKey:           opt.ApiKey,
+		signer:           signer,
+		baseUrl:          baseUrl,
+		httpClient:       httpClient,
+		maxRetries:       maxRetries,
+		limiter:          newWeightLimiter(mexcWeightLimit, mexcWeightWindow),
+		recvWindow:       recvWindow,
+		now:              clock,
+		timeSyncInterval: timeSyncInterval,
 	}
 }
 
-func (m *Mexc) CreateSpotOrder(ctx context.Context, order *types.SpotOrder) error {
+func (m *Mexc) Name() commonTypes.Exchange {
+	return commonTypes.ExchangeMexc
+}
+
+func (m *Mexc) CreateSpotOrder(ctx context.Context, order *types.SpotOrder) (commonTypes.OrderID, error) {
 	orderPosition, ok := mexcOrderPosition[order.Position]
 	if !ok {
-		return ErrMexcOrderSideNotFound
+		return "", ErrMexcOrderSideNotFound
 	}
 
 	orderType, ok := mexcOrderType[order.Type]
 	if !ok {
-		return ErrMexcOrderTypeNotFound
+		return "", ErrMexcOrderTypeNotFound
 	}
 
 	queryParams := url.Values{}
 	queryParams.Set("symbol", fmt.Sprintf("%s%s", order.Symbol, order.BaseSymbol))
 	queryParams.Set("side", orderPosition)
 	queryParams.Set("type", orderType)
-	queryParams.Set("quantity", strconv.FormatFloat(order.Quantity, 'f', 6, 64))
-	queryParams.Set("price", strconv.FormatFloat(order.Entry, 'f', 6, 64))
+	queryParams.Set("quantity", strconv.FormatFloat(order.Quantity, 'f', -1, 64))
+	// market order is filled at any price
+	if order.Type != commonTypes.OrderTypeMarket {
+		queryParams.Set("price", strconv.FormatFloat(order.Entry, 'f', -1, 64))
+	}
+	// order with client id is safe to retry, exchange rejects its duplicate
+	if order.ClientOrderID != "" {
+		queryParams.Set("newClientOrderId", order.ClientOrderID)
+	}
 
 	bytes, err := m.doRequest(ctx, http.MethodPost, "/api/v3/order", queryParams)
 	if err != nil {
-		return fmt.Errorf("Mexc::CreateOrder : %w", err)
+		return "", fmt.Errorf("Mexc::CreateOrder : %w", err)
 	}
 
 	var orderRecv orderCreated
 	err = json.Unmarshal(bytes, &orderRecv)
 	if err != nil {
-		return fmt.Errorf("Mexc::CreateOrder : %w", err)
+		return "", fmt.Errorf("Mexc::CreateOrder : %w", err)
 	}
 
-	return nil
+	return commonTypes.OrderID(orderRecv.OrderID), nil
 }
 
 func (m *Mexc) CancelAllOrders(ctx context.Context, symbol, baseSymbol string) error {
@@ -128,6 +363,157 @@ func (m *Mexc) CancelAllOrders(ctx context.Context, symbol, baseSymbol string) e
 	return nil
 }
 
+func (m *Mexc) CancelOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) error {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", fmt.Sprintf("%s%s", symbol, baseSymbol))
+	queryParams.Set("orderId", string(orderID))
+
+	_, err := m.doRequest(ctx, http.MethodDelete, "/api/v3/order", queryParams)
+	if err != nil {
+		return fmt.Errorf("Mexc::CancelOrder : %w", err)
+	}
+
+	return nil
+}
+
+func (m *Mexc) GetOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) (*commonTypes.Order, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", fmt.Sprintf("%s%s", symbol, baseSymbol))
+	queryParams.Set("orderId", string(orderID))
+
+	order, err := m.getOrder(ctx, symbol, baseSymbol, queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("Mexc::GetOrder : %w", err)
+	}
+
+	return order, nil
+}
+
+// GetOrderByClientID returns order placed with client order id, ErrOrderNotFound when there is none
+func (m *Mexc) GetOrderByClientID(ctx context.Context, symbol, baseSymbol, clientOrderID string) (*commonTypes.Order, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", fmt.Sprintf("%s%s", symbol, baseSymbol))
+	queryParams.Set("origClientOrderId", clientOrderID)
+
+	order, err := m.getOrder(ctx, symbol, baseSymbol, queryParams)
+	var mexcErr *MexcError
+	if errors.As(err, &mexcErr) && mexcErr.Code == mexcOrderNotExistCode {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Mexc::GetOrderByClientID : %w", err)
+	}
+
+	return order, nil
+}
+
+func (m *Mexc) getOrder(ctx context.Context, symbol, baseSymbol string, queryParams url.Values) (*commonTypes.Order, error) {
+	bytes, err := m.doRequest(ctx, http.MethodGet, "/api/v3/order", queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderRecv orderMexc
+	err = json.Unmarshal(bytes, &orderRecv)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := newOrderFromMexc(&orderRecv)
+	if err != nil {
+		return nil, err
+	}
+
+	// order itself has no commission, it is paid by trades order is filled with
+	if order.ExecutedQuantity > 0 {
+		order.Fee, order.QuantityFee, err = m.getOrderFee(ctx, symbol, baseSymbol, commonTypes.OrderID(order.OrderID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// getOrderFee sums commissions of trades order is filled with separately for quote and filled asset.
+// Commission paid in any other asset, e.g. MX, is charged from its own balance and is left out.
+func (m *Mexc) getOrderFee(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) (float64, float64, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", fmt.Sprintf("%s%s", symbol, baseSymbol))
+	queryParams.Set("orderId", string(orderID))
+
+	bytes, err := m.doRequest(ctx, http.MethodGet, "/api/v3/myTrades", queryParams)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var trades []tradeMexc
+	if err := json.Unmarshal(bytes, &trades); err != nil {
+		return 0, 0, err
+	}
+
+	fee, quantityFee := 0.0, 0.0
+	for _, trade := range trades {
+		commission, err := strconv.ParseFloat(trade.Commission, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("can't parse commission to float: %w", err)
+		}
+
+		switch trade.CommissionAsset {
+		case baseSymbol:
+			fee += commission
+		case symbol:
+			quantityFee += commission
+		}
+	}
+
+	return fee, quantityFee, nil
+}
+
+// GetSymbolInfo reads filters of symbol from exchangeInfo on every call. Request weighs 10,
+// so callers keep the result, as rules.Normalizer does for an hour.
+func (m *Mexc) GetSymbolInfo(ctx context.Context, symbol, baseSymbol string) (*types.SymbolInfo, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", fmt.Sprintf("%s%s", symbol, baseSymbol))
+
+	bytes, err := m.doRequest(ctx, http.MethodGet, "/api/v3/exchangeInfo", queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("Mexc::GetSymbolInfo : %w", err)
+	}
+
+	var info exchangeInfoMexc
+	err = json.Unmarshal(bytes, &info)
+	if err != nil {
+		return nil, fmt.Errorf("Mexc::GetSymbolInfo : %w", err)
+	}
+
+	for _, s := range info.Symbols {
+		if s.BaseAsset != symbol || s.QuoteAsset != baseSymbol {
+			continue
+		}
+
+		symbolInfo := &types.SymbolInfo{
+			Symbol:     s.BaseAsset,
+			BaseSymbol: s.QuoteAsset,
+			TickSize:   math.Pow10(-s.QuotePrecision),
+			StepSize:   math.Pow10(-s.BaseAssetPrecision),
+		}
+
+		if stepSize, err := strconv.ParseFloat(s.BaseSizePrecision, 64); err == nil && stepSize > 0 {
+			symbolInfo.StepSize = stepSize
+		}
+		symbolInfo.MinQuantity = symbolInfo.StepSize
+
+		if minNotional, err := strconv.ParseFloat(s.QuoteAmountPrecision, 64); err == nil {
+			symbolInfo.MinNotional = minNotional
+		}
+
+		return symbolInfo, nil
+	}
+
+	return nil, ErrMexcSymbolNotFound
+}
+
 func (m *Mexc) GetPrice(ctx context.Context, symbol, baseSymbol string) (float64, error) {
 	currency := fmt.Sprintf("%s%s", symbol, baseSymbol)
 
@@ -153,63 +539,446 @@ func (m *Mexc) GetPrice(ctx context.Context, symbol, baseSymbol string) (float64
 	return floatValue, nil
 }
 
-func (m *Mexc) doRequest(_ context.Context, method, url string, queryParams url.Values) ([]byte, error) {
-	queryParams.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+// GetCandles returns the latest candles from the oldest to the newest, mexcCandleLimit candles are returned without limit
+func (m *Mexc) GetCandles(ctx context.Context, symbol, baseSymbol string, interval commonTypes.CandleInterval, limit int) ([]*commonTypes.Candle, error) {
+	candleInterval, ok := mexcCandleInterval[interval]
+	if !ok {
+		return nil, ErrMexcIntervalNotFound
+	}
+	if limit <= 0 {
+		limit = mexcCandleLimit
+	}
+
+	queryParams := url.Values{}
+	queryParams.Set("symbol", fmt.Sprintf("%s%s", symbol, baseSymbol))
+	queryParams.Set("interval", candleInterval)
+	queryParams.Set("limit", strconv.Itoa(limit))
 
-	mac := hmac.New(sha256.New, []byte(m.secretKey))
-	mac.Write([]byte(queryParams.Encode()))
+	bytes, err := m.doRequest(ctx, http.MethodGet, "/api/v3/klines", queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("Mexc::GetCandles : %w", err)
+	}
 
-	queryParams.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+	// every kline is [openTime, open, high, low, close, volume, closeTime, quoteVolume]
+	var klines [][]any
+	if err := json.Unmarshal(bytes, &klines); err != nil {
+		return nil, fmt.Errorf("Mexc::GetCandles : %w", err)
+	}
 
-	requestURL := fmt.Sprintf("%s%s?%s", baseURL, url, queryParams.Encode())
+	candles := make([]*commonTypes.Candle, 0, len(klines))
+	for _, kline := range klines {
+		candle, err := newCandleFromMexc(kline, interval)
+		if err != nil {
+			return nil, fmt.Errorf("Mexc::GetCandles : %w", err)
+		}
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
 
-	req, err := http.NewRequest(method, requestURL, nil)
+// doRequest sends signed request. Requests safe to repeat, which are reads, cancels and orders
+// with client order id, are retried with backoff on network errors, 429 and 5xx responses.
+func (m *Mexc) doRequest(ctx context.Context, method, path string, queryParams url.Values) ([]byte, error) {
+	retry := method != http.MethodPost || queryParams.Get("newClientOrderId") != ""
+	weight := mexcWeight(path, queryParams)
+
+	resynced := false
+	for attempt := 0; ; attempt++ {
+		// request is signed with the last known drift when exchange clock can't be read
+		_ = m.syncTime(ctx, false)
+
+		if err := m.limiter.Wait(ctx, weight); err != nil {
+			return nil, fmt.Errorf("doRequest : %w", err)
+		}
+
+		body, retryAfter, err := m.send(ctx, method, path, queryParams)
+		if err == nil {
+			return body, nil
+		}
+
+		// request with stale timestamp is rejected before it is executed, so it is safe to send it once more
+		if CategoryOf(err) == ErrorCategoryTimestamp && !resynced {
+			resynced = true
+			if syncErr := m.syncTime(ctx, true); syncErr != nil {
+				return nil, err
+			}
+			attempt--
+
+			continue
+		}
+
+		if !retry || retryAfter < 0 || attempt >= m.maxRetries {
+			return nil, err
+		}
+
+		delay := mexcRetryBaseDelay << attempt
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("doRequest : %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// serverNow returns local time corrected by measured drift from exchange clock
+func (m *Mexc) serverNow() time.Time {
+	m.timeMu.Lock()
+	defer m.timeMu.Unlock()
+
+	return m.now().Add(m.timeOffset)
+}
+
+// syncTime measures drift of local clock from exchange clock when it is older than sync interval or forced.
+// Exchange clock is read outside of lock and only by one request at a time, after failure it isn't read
+// again until retry delay passes unless forced.
+func (m *Mexc) syncTime(ctx context.Context, force bool) error {
+	m.timeMu.Lock()
+	now := m.now()
+	stale := m.timeSyncedAt.IsZero() || now.Sub(m.timeSyncedAt) >= m.timeSyncInterval
+	failedRecently := !m.timeSyncFailedAt.IsZero() && now.Sub(m.timeSyncFailedAt) < mexcTimeSyncRetryDelay
+	if m.timeSyncing || !(force || stale && !failedRecently) {
+		m.timeMu.Unlock()
+
+		return nil
+	}
+	m.timeSyncing = true
+	m.timeMu.Unlock()
+
+	offset, syncedAt, err := m.readTimeOffset(ctx)
+
+	m.timeMu.Lock()
+	defer m.timeMu.Unlock()
+
+	m.timeSyncing = false
+	if err != nil {
+		m.timeSyncFailedAt = m.now()
+
+		return fmt.Errorf("Mexc::syncTime : %w", err)
+	}
+	m.timeOffset = offset
+	m.timeSyncedAt = syncedAt
+	m.timeSyncFailedAt = time.Time{}
+
+	return nil
+}
+
+// readTimeOffset reads exchange clock and returns its drift from local clock together with time it is read at
+func (m *Mexc) readTimeOffset(ctx context.Context) (time.Duration, time.Time, error) {
+	if err := m.limiter.Wait(ctx, 1); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseUrl+"/api/v3/time", nil)
 	if err != nil {
-		return nil, fmt.Errorf("doRequest : %w", err)
+		return 0, time.Time{}, err
+	}
+
+	sentAt := m.now()
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer resp.Body.Close()
+	receivedAt := m.now()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, time.Time{}, newMexcError(resp.StatusCode, body)
+	}
+
+	var serverTime serverTimeMexc
+	if err := json.Unmarshal(body, &serverTime); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	// server time is taken at the middle of round trip
+	localTime := sentAt.Add(receivedAt.Sub(sentAt) / 2)
+
+	return time.UnixMilli(serverTime.ServerTime).Sub(localTime), receivedAt, nil
+}
+
+// send makes a single signed request. Negative retryAfter means request must not be repeated.
+func (m *Mexc) send(ctx context.Context, method, path string, queryParams url.Values) ([]byte, time.Duration, error) {
+	queryParams.Set("timestamp", strconv.FormatInt(m.serverNow().UnixMilli(), 10))
+	queryParams.Set("recvWindow", strconv.FormatInt(m.recvWindow.Milliseconds(), 10))
+	queryParams.Del("signature")
+	queryParams.Set("signature", m.signer.Sign(queryParams.Encode()))
+
+	requestURL := fmt.Sprintf("%s%s?%s", m.baseUrl, path, queryParams.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return nil, -1, fmt.Errorf("doRequest : %w", err)
 	}
 
 	req.Header.Add("X-MEXC-APIKEY", m.apiKey)
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := m.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+		if ctx.Err() != nil {
+			return nil, -1, fmt.Errorf("error sending request: %w", ctx.Err())
+		}
+
+		return nil, 0, fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response : %w", err)
+		return nil, 0, fmt.Errorf("error reading response : %w", err)
 	}
 
 	if resp.StatusCode >= 400 && resp.StatusCode < 600 {
-		return nil, fmt.Errorf("received error status %d with message: %s", resp.StatusCode, string(body))
+		mexcErr := newMexcError(resp.StatusCode, body)
+
+		retryAfter := time.Duration(-1)
+		if mexcErr.Category() == ErrorCategoryRateLimit || resp.StatusCode >= 500 {
+			retryAfter = 0
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				retryAfter = time.Duration(seconds) * time.Second
+			}
+		}
+
+		return nil, retryAfter, mexcErr
 	}
 
-	return body, nil
+	return body, 0, nil
 }
 
+// GetAssets returns free amount of asset, asset account doesn't hold has zero amount
 func (m *Mexc) GetAssets(ctx context.Context, symbol string) (float64, error) {
-	bytes, err := m.doRequest(ctx, http.MethodGet, "/api/v3/account", url.Values{})
+	balances, err := m.getBalances(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("Mexc::GetAssets : %w", err)
 	}
 
-	var account accountMexc
-	err = json.Unmarshal(bytes, &account)
+	for _, balance := range balances {
+		if balance.Asset == symbol {
+			return balance.Free, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// GetPortfolio returns every asset of account valued in quote symbol by the last ticker prices
+func (m *Mexc) GetPortfolio(ctx context.Context, quoteSymbol string) (*types.Portfolio, error) {
+	balances, err := m.getBalances(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("Mexc::GetAssets : %w", err)
+		return nil, fmt.Errorf("Mexc::GetPortfolio : %w", err)
+	}
+
+	prices, err := m.getPrices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Mexc::GetPortfolio : %w", err)
+	}
+
+	portfolio := &types.Portfolio{
+		QuoteSymbol: quoteSymbol,
+		Balances:    balances,
+	}
+	for _, balance := range balances {
+		balance.Value = balance.Total() * quotePrice(prices, balance.Asset, quoteSymbol)
+		portfolio.Value += balance.Value
 	}
 
+	return portfolio, nil
+}
+
+func (m *Mexc) getBalances(ctx context.Context) ([]*types.Balance, error) {
+	bytes, err := m.doRequest(ctx, http.MethodGet, "/api/v3/account", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var account accountMexc
+	if err := json.Unmarshal(bytes, &account); err != nil {
+		return nil, err
+	}
+
+	balances := make([]*types.Balance, 0, len(account.Balances))
 	for _, balance := range account.Balances {
-		if balance.Currency == symbol {
-			floatValue, err := strconv.ParseFloat(balance.Free, 64)
-			if err != nil {
-				return 0, fmt.Errorf("Mexc::GetAssets : %w", err)
-			}
+		amounts, err := parseFloats(balance.Free, balance.Locked)
+		if err != nil {
+			return nil, err
+		}
+
+		balances = append(balances, &types.Balance{
+			Asset:  balance.Currency,
+			Free:   amounts[0],
+			Locked: amounts[1],
+		})
+	}
+
+	return balances, nil
+}
+
+// mexcWeight returns weight of request in ip rate limit, endpoints not listed weigh 1
+func mexcWeight(path string, queryParams url.Values) int {
+	if queryParams.Get("symbol") == "" {
+		if weight, ok := mexcAllSymbolsWeight[path]; ok {
+			return weight
+		}
+	}
+	if weight, ok := mexcRequestWeight[path]; ok {
+		return weight
+	}
+
+	return 1
+}
+
+// getPrices returns the last price of every symbol exchange trades
+func (m *Mexc) getPrices(ctx context.Context) (map[string]float64, error) {
+	bytes, err := m.doRequest(ctx, http.MethodGet, "/api/v3/ticker/price", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var pricesRecv []currencyPrice
+	if err := json.Unmarshal(bytes, &pricesRecv); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(pricesRecv))
+	for _, price := range pricesRecv {
+		floatValue, err := strconv.ParseFloat(price.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse price to float: %w", err)
+		}
+		prices[price.Symbol] = floatValue
+	}
+
+	return prices, nil
+}
+
+// quotePrice returns price of asset in quote symbol by direct or inverse pair, zero when neither is traded
+func quotePrice(prices map[string]float64, asset, quoteSymbol string) float64 {
+	if asset == quoteSymbol {
+		return 1
+	}
+	if price, ok := prices[asset+quoteSymbol]; ok {
+		return price
+	}
+	if price, ok := prices[quoteSymbol+asset]; ok && price > 0 {
+		return 1 / price
+	}
+
+	return 0
+}
+
+func newOrderFromMexc(orderRecv *orderMexc) (*commonTypes.Order, error) {
+	status, ok := mexcOrderStatus[orderRecv.Status]
+	if !ok {
+		return nil, ErrMexcOrderStatusNotFound
+	}
+
+	side, ok := mexcOrderSide[orderRecv.Side]
+	if !ok {
+		return nil, ErrMexcOrderSideNotFound
+	}
+
+	var orderType commonTypes.OrderType
+	for t, name := range mexcOrderType {
+		if name == orderRecv.Type {
+			orderType = t
+			break
+		}
+	}
+	if orderType == "" {
+		return nil, ErrMexcOrderTypeNotFound
+	}
+
+	quantity, err := strconv.ParseFloat(orderRecv.Quantity, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse quantity to float: %w", err)
+	}
+
+	price, err := strconv.ParseFloat(orderRecv.Price, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse price to float: %w", err)
+	}
+
+	filled, err := parseFloats(orderRecv.ExecutedQuantity, orderRecv.CummulativeQuoteQty)
+	if err != nil {
+		return nil, err
+	}
+
+	averagePrice := 0.0
+	if filled[0] > 0 {
+		averagePrice = filled[1] / filled[0]
+	}
+
+	return &commonTypes.Order{
+		OrderID:          orderRecv.OrderID,
+		ClientOrderID:    orderRecv.ClientOrderID,
+		Currency:         orderRecv.Currency,
+		Side:             side,
+		Type:             orderType,
+		Quantity:         quantity,
+		Price:            price,
+		Status:           status,
+		ExecutedQuantity: filled[0],
+		AveragePrice:     averagePrice,
+	}, nil
+}
+
+// newMexcError parses {code,msg} body of failed request, body of other shape is kept as message
+func newMexcError(statusCode int, body []byte) *MexcError {
+	var errorRecv errorMexc
+	if err := json.Unmarshal(body, &errorRecv); err != nil || (errorRecv.Code == 0 && errorRecv.Message == "") {
+		return &MexcError{StatusCode: statusCode, Message: string(body)}
+	}
+
+	return &MexcError{
+		StatusCode: statusCode,
+		Code:       errorRecv.Code,
+		Message:    errorRecv.Message,
+	}
+}
 
-			return floatValue, nil
+func newCandleFromMexc(kline []any, interval commonTypes.CandleInterval) (*commonTypes.Candle, error) {
+	if len(kline) < 8 {
+		return nil, fmt.Errorf("mexc kline has %d fields", len(kline))
+	}
+
+	openTime, okOpen := kline[0].(float64)
+	closeTime, okClose := kline[6].(float64)
+	if !okOpen || !okClose {
+		return nil, fmt.Errorf("mexc kline time is not a number")
+	}
+
+	values := make([]string, 0, 6)
+	for _, i := range []int{1, 2, 3, 4, 5, 7} {
+		value, ok := kline[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("mexc kline value %d is not a string", i)
 		}
+		values = append(values, value)
+	}
+
+	floats, err := parseFloats(values...)
+	if err != nil {
+		return nil, err
 	}
 
-	return 0, ErrAssetNotFound
+	return &commonTypes.Candle{
+		OpenTime:    time.UnixMilli(int64(openTime)),
+		CloseTime:   time.UnixMilli(int64(closeTime)),
+		Open:        floats[0],
+		High:        floats[1],
+		Low:         floats[2],
+		Close:       floats[3],
+		Volume:      floats[4],
+		AssetVolume: floats[5],
+		Interval:    interval,
+	}, nil
 }
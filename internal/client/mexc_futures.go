@@ -0,0 +1,728 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+const (
+	mexcFuturesBaseURL        string        = "https://contract.mexc.com"
+	mexcFuturesDefaultTimeout time.Duration = 10 * time.Second
+
+	// contract API lets 20 requests through every 2 seconds
+	mexcFuturesRequestLimit  int           = 20
+	mexcFuturesRequestWindow time.Duration = 2 * time.Second
+
+	mexcFuturesOpenLong   int = 1
+	mexcFuturesCloseShort int = 2
+	mexcFuturesOpenShort  int = 3
+	mexcFuturesCloseLong  int = 4
+
+	mexcFuturesPositionLong  int = 1
+	mexcFuturesPositionShort int = 2
+
+	mexcFuturesStateUninformed  int = 1
+	mexcFuturesStateUncompleted int = 2
+	mexcFuturesStateCompleted   int = 3
+	mexcFuturesStateCancelled   int = 4
+	mexcFuturesStateInvalid     int = 5
+)
+
+type mexcFuturesResponse struct {
+	Success bool            `json:"success"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type tickerMexcFutures struct {
+	Symbol    string  `json:"symbol"`
+	LastPrice float64 `json:"lastPrice"`
+}
+
+type assetMexcFutures struct {
+	Currency         string  `json:"currency"`
+	AvailableBalance float64 `json:"availableBalance"`
+}
+
+type contractMexcFutures struct {
+	Symbol       string  `json:"symbol"`
+	BaseCoin     string  `json:"baseCoin"`
+	QuoteCoin    string  `json:"quoteCoin"`
+	ContractSize float64 `json:"contractSize"`
+	PriceUnit    float64 `json:"priceUnit"`
+	VolUnit      float64 `json:"volUnit"`
+	MinVol       float64 `json:"minVol"`
+	MaxLeverage  float64 `json:"maxLeverage"`
+}
+
+type orderCreateMexcFutures struct {
+	Symbol          string  `json:"symbol"`
+	Price           float64 `json:"price,omitempty"`
+	Vol             float64 `json:"vol"`
+	Leverage        float64 `json:"leverage,omitempty"`
+	Side            int     `json:"side"`
+	Type            int     `json:"type"`
+	OpenType        int     `json:"openType"`
+	StopLossPrice   float64 `json:"stopLossPrice,omitempty"`
+	TakeProfitPrice float64 `json:"takeProfitPrice,omitempty"`
+	ReduceOnly      bool    `json:"reduceOnly,omitempty"`
+	ExternalOid     string  `json:"externalOid,omitempty"`
+}
+
+type orderMexcFutures struct {
+	OrderID      json.Number `json:"orderId"`
+	ExternalOid  string      `json:"externalOid"`
+	Symbol       string      `json:"symbol"`
+	Price        float64     `json:"price"`
+	Vol          float64     `json:"vol"`
+	Side         int         `json:"side"`
+	OrderType    int         `json:"orderType"`
+	DealAvgPrice float64     `json:"dealAvgPrice"`
+	DealVol      float64     `json:"dealVol"`
+	TakerFee     float64     `json:"takerFee"`
+	MakerFee     float64     `json:"makerFee"`
+	State        int         `json:"state"`
+}
+
+type leverageMexcFutures struct {
+	Symbol       string  `json:"symbol"`
+	Leverage     float64 `json:"leverage"`
+	OpenType     int     `json:"openType"`
+	PositionType int     `json:"positionType"`
+}
+
+type cancelAllMexcFutures struct {
+	Symbol string `json:"symbol"`
+}
+
+type positionMexcFutures struct {
+	Symbol         string  `json:"symbol"`
+	PositionType   int     `json:"positionType"`
+	HoldVol        float64 `json:"holdVol"`
+	HoldAvgPrice   float64 `json:"holdAvgPrice"`
+	LiquidatePrice float64 `json:"liquidatePrice"`
+	Leverage       float64 `json:"leverage"`
+}
+
+// MexcFuturesError is an error returned by MEXC contract API in code and message or by HTTP status
+type MexcFuturesError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+func (e *MexcFuturesError) Error() string {
+	return fmt.Sprintf("mexc futures error %d (status %d, %s): %s", e.Code, e.StatusCode, e.Category(), e.Message)
+}
+
+// Category groups MEXC contract error codes, status code is used for errors without known code
+func (e *MexcFuturesError) Category() ErrorCategory {
+	if category, ok := mexcFuturesErrorCategory[e.Code]; ok {
+		return category
+	}
+
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorCategoryAuth
+	case http.StatusTooManyRequests:
+		return ErrorCategoryRateLimit
+	}
+
+	return ErrorCategoryUnknown
+}
+
+var (
+	_ FuturesClient     = (*MexcFutures)(nil)
+	_ OrderLookupClient = (*MexcFutures)(nil)
+)
+
+// MexcFuturesOptions holds configuration options for the MEXC contract client.
+type MexcFuturesOptions struct {
+	ApiKey     string
+	ApiSecret  string
+	BaseURL    string
+	MarginMode commonTypes.MarginMode
+	HTTPClient *http.Client
+	// MaxRetries is how many times failed idempotent request is repeated, negative disables retries
+	MaxRetries int
+}
+
+// MexcFutures is a client for MEXC contract API able to open leveraged long and short positions.
+// Quantities are passed in base symbol and converted to contracts by client.
+type MexcFutures struct {
+	apiKey     string
+	secretKey  string
+	baseUrl    string
+	marginMode commonTypes.MarginMode
+	httpClient *http.Client
+	maxRetries int
+	limiter    *weightLimiter
+
+	mu        sync.RWMutex
+	contracts map[string]*contractMexcFutures
+}
+
+var (
+	mexcFuturesOrderType = map[commonTypes.OrderType]int{
+		commonTypes.OrderTypeLimit:             1,
+		commonTypes.OrderTypeImmediateOrCancel: 3,
+		commonTypes.OrderTypeFillOrKill:        4,
+		commonTypes.OrderTypeMarket:            5,
+	}
+
+	mexcFuturesOpenType = map[commonTypes.MarginMode]int{
+		commonTypes.MarginModeIsolated: 1,
+		commonTypes.MarginModeCross:    2,
+	}
+
+	mexcFuturesPosition = map[int]commonTypes.Position{
+		mexcFuturesPositionLong:  commonTypes.PositionLong,
+		mexcFuturesPositionShort: commonTypes.PositionShort,
+	}
+
+	mexcFuturesErrorCategory = map[int]ErrorCategory{
+		401:  ErrorCategoryAuth,      // unauthorized
+		402:  ErrorCategoryAuth,      // api key expired
+		406:  ErrorCategoryAuth,      // ip not in whitelist
+		602:  ErrorCategoryAuth,      // signature verification failed
+		2005: ErrorCategoryBalance,   // insufficient balance
+		1001: ErrorCategorySymbol,    // contract doesn't exist
+		1002: ErrorCategorySymbol,    // contract not activated
+		510:  ErrorCategoryRateLimit, // excessive frequency of requests
+		513:  ErrorCategoryTimestamp, // request time too far from server time
+	}
+)
+
+var (
+	ErrMexcFuturesOrderSideNotFound   = errors.New("mexc futures order side not found")
+	ErrMexcFuturesOrderTypeNotFound   = errors.New("mexc futures order type not found")
+	ErrMexcFuturesOrderStatusNotFound = errors.New("mexc futures order status not found")
+	ErrMexcFuturesMarginModeNotFound  = errors.New("mexc futures margin mode not found")
+	ErrMexcFuturesSymbolNotFound      = errors.New("mexc futures symbol not found")
+	ErrMexcFuturesVolumeTooSmall      = errors.New("mexc futures volume below one volume unit")
+)
+
+// NewMexcFutures creates a new MEXC contract client. Positions are isolated unless margin mode is set.
+func NewMexcFutures(opt *MexcFuturesOptions) *MexcFutures {
+	baseUrl := opt.BaseURL
+	if baseUrl == "" {
+		baseUrl = mexcFuturesBaseURL
+	}
+
+	marginMode := opt.MarginMode
+	if marginMode == "" {
+		marginMode = commonTypes.MarginModeIsolated
+	}
+
+	httpClient := opt.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: mexcFuturesDefaultTimeout}
+	}
+
+	maxRetries := opt.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = mexcDefaultMaxRetries
+	}
+
+	return &MexcFutures{
+		apiKey:     opt.ApiKey,
+		secretKey:  opt.ApiSecret,
+		baseUrl:    baseUrl,
+		marginMode: marginMode,
+		httpClient: httpClient,
+		maxRetries: maxRetries,
+		limiter:    newWeightLimiter(mexcFuturesRequestLimit, mexcFuturesRequestWindow),
+		contracts:  make(map[string]*contractMexcFutures),
+	}
+}
+
+func (m *MexcFutures) Name() commonTypes.Exchange {
+	return commonTypes.ExchangeMexc
+}
+
+func (m *MexcFutures) GetPrice(ctx context.Context, symbol, baseSymbol string) (float64, error) {
+	queryParams := url.Values{}
+	queryParams.Set("symbol", mexcFuturesSymbol(symbol, baseSymbol))
+
+	var ticker tickerMexcFutures
+	if err := m.doRequest(ctx, http.MethodGet, "/api/v1/contract/ticker", queryParams, nil, &ticker); err != nil {
+		return 0, fmt.Errorf("MexcFutures::GetPrice : %w", err)
+	}
+
+	return ticker.LastPrice, nil
+}
+
+func (m *MexcFutures) GetAssets(ctx context.Context, symbol string) (float64, error) {
+	var asset assetMexcFutures
+	if err := m.doRequest(ctx, http.MethodGet, "/api/v1/private/account/asset/"+url.PathEscape(symbol), nil, nil, &asset); err != nil {
+		return 0, fmt.Errorf("MexcFutures::GetAssets : %w", err)
+	}
+
+	return asset.AvailableBalance, nil
+}
+
+// SetLeverage sets leverage of long and short positions in client margin mode
+func (m *MexcFutures) SetLeverage(ctx context.Context, symbol, baseSymbol string, leverage float64) error {
+	openType, ok := mexcFuturesOpenType[m.marginMode]
+	if !ok {
+		return ErrMexcFuturesMarginModeNotFound
+	}
+
+	for _, positionType := range []int{mexcFuturesPositionLong, mexcFuturesPositionShort} {
+		if err := m.doRequest(ctx, http.MethodPost, "/api/v1/private/position/change_leverage", nil, &leverageMexcFutures{
+			Symbol:       mexcFuturesSymbol(symbol, baseSymbol),
+			Leverage:     leverage,
+			OpenType:     openType,
+			PositionType: positionType,
+		}, nil); err != nil {
+			return fmt.Errorf("MexcFutures::SetLeverage : %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *MexcFutures) CreateFuturesOrder(ctx context.Context, order *types.FuturesOrder) (commonTypes.OrderID, error) {
+	var side int
+	switch {
+	case order.Position == commonTypes.PositionLong && !order.ReduceOnly:
+		side = mexcFuturesOpenLong
+	case order.Position == commonTypes.PositionLong && order.ReduceOnly:
+		side = mexcFuturesCloseLong
+	case order.Position == commonTypes.PositionShort && !order.ReduceOnly:
+		side = mexcFuturesOpenShort
+	case order.Position == commonTypes.PositionShort && order.ReduceOnly:
+		side = mexcFuturesCloseShort
+	default:
+		return "", ErrMexcFuturesOrderSideNotFound
+	}
+
+	orderType, ok := mexcFuturesOrderType[order.Type]
+	if !ok {
+		return "", ErrMexcFuturesOrderTypeNotFound
+	}
+
+	marginMode := order.MarginMode
+	if marginMode == "" {
+		marginMode = m.marginMode
+	}
+	openType, ok := mexcFuturesOpenType[marginMode]
+	if !ok {
+		return "", ErrMexcFuturesMarginModeNotFound
+	}
+
+	contract, err := m.getContract(ctx, order.Symbol, order.BaseSymbol)
+	if err != nil {
+		return "", fmt.Errorf("MexcFutures::CreateFuturesOrder : %w", err)
+	}
+
+	vol, err := contractVolume(order.Quantity, contract)
+	if err != nil {
+		return "", fmt.Errorf("MexcFutures::CreateFuturesOrder : %w", err)
+	}
+
+	request := &orderCreateMexcFutures{
+		Symbol:          contract.Symbol,
+		Vol:             vol,
+		Leverage:        order.Leverage,
+		Side:            side,
+		Type:            orderType,
+		OpenType:        openType,
+		StopLossPrice:   order.StopLoss,
+		TakeProfitPrice: order.TakeProfit,
+		ReduceOnly:      order.ReduceOnly,
+		ExternalOid:     order.ClientOrderID,
+	}
+	if order.Type != commonTypes.OrderTypeMarket {
+		request.Price = order.Entry
+	}
+
+	var orderID json.Number
+	if err := m.doRequest(ctx, http.MethodPost, "/api/v1/private/order/submit", nil, request, &orderID); err != nil {
+		return "", fmt.Errorf("MexcFutures::CreateFuturesOrder : %w", err)
+	}
+
+	return commonTypes.OrderID(orderID.String()), nil
+}
+
+func (m *MexcFutures) GetOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) (*commonTypes.Order, error) {
+	contract, err := m.getContract(ctx, symbol, baseSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetOrder : %w", err)
+	}
+
+	var orderRecv orderMexcFutures
+	if err := m.doRequest(ctx, http.MethodGet, "/api/v1/private/order/get/"+url.PathEscape(string(orderID)), nil, nil, &orderRecv); err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetOrder : %w", err)
+	}
+
+	order, err := newOrderFromMexcFutures(&orderRecv, contract)
+	if err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetOrder : %w", err)
+	}
+
+	return order, nil
+}
+
+// GetOrderByClientID returns order placed with external order id, ErrOrderNotFound when there is none
+func (m *MexcFutures) GetOrderByClientID(ctx context.Context, symbol, baseSymbol, clientOrderID string) (*commonTypes.Order, error) {
+	contract, err := m.getContract(ctx, symbol, baseSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetOrderByClientID : %w", err)
+	}
+
+	path := "/api/v1/private/order/external/" + url.PathEscape(contract.Symbol) + "/" + url.PathEscape(clientOrderID)
+
+	var orderRecv orderMexcFutures
+	if err := m.doRequest(ctx, http.MethodGet, path, nil, nil, &orderRecv); err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetOrderByClientID : %w", err)
+	}
+	// unknown external id is answered with empty data
+	if orderRecv.OrderID == "" {
+		return nil, ErrOrderNotFound
+	}
+
+	order, err := newOrderFromMexcFutures(&orderRecv, contract)
+	if err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetOrderByClientID : %w", err)
+	}
+
+	return order, nil
+}
+
+func (m *MexcFutures) CancelOrder(ctx context.Context, symbol, baseSymbol string, orderID commonTypes.OrderID) error {
+	if err := m.doRequest(ctx, http.MethodPost, "/api/v1/private/order/cancel", nil, []json.Number{json.Number(orderID)}, nil); err != nil {
+		return fmt.Errorf("MexcFutures::CancelOrder : %w", err)
+	}
+
+	return nil
+}
+
+func (m *MexcFutures) CancelAllOrders(ctx context.Context, symbol, baseSymbol string) error {
+	if err := m.doRequest(ctx, http.MethodPost, "/api/v1/private/order/cancel_all", nil, &cancelAllMexcFutures{
+		Symbol: mexcFuturesSymbol(symbol, baseSymbol),
+	}, nil); err != nil {
+		return fmt.Errorf("MexcFutures::CancelAllOrders : %w", err)
+	}
+
+	return nil
+}
+
+func (m *MexcFutures) GetPositions(ctx context.Context, symbol, baseSymbol string) ([]*types.OpenPosition, error) {
+	contract, err := m.getContract(ctx, symbol, baseSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetPositions : %w", err)
+	}
+
+	queryParams := url.Values{}
+	queryParams.Set("symbol", contract.Symbol)
+
+	var positions []positionMexcFutures
+	if err := m.doRequest(ctx, http.MethodGet, "/api/v1/private/position/open_positions", queryParams, nil, &positions); err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetPositions : %w", err)
+	}
+
+	result := make([]*types.OpenPosition, 0, len(positions))
+	for _, p := range positions {
+		position, ok := mexcFuturesPosition[p.PositionType]
+		if !ok {
+			continue
+		}
+
+		result = append(result, &types.OpenPosition{
+			Symbol:           symbol,
+			BaseSymbol:       baseSymbol,
+			Position:         position,
+			Quantity:         p.HoldVol * contract.ContractSize,
+			Entry:            p.HoldAvgPrice,
+			Leverage:         p.Leverage,
+			LiquidationPrice: p.LiquidatePrice,
+		})
+	}
+
+	return result, nil
+}
+
+func (m *MexcFutures) GetSymbolInfo(ctx context.Context, symbol, baseSymbol string) (*types.SymbolInfo, error) {
+	contract, err := m.getContract(ctx, symbol, baseSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("MexcFutures::GetSymbolInfo : %w", err)
+	}
+
+	return &types.SymbolInfo{
+		Symbol:      contract.BaseCoin,
+		BaseSymbol:  contract.QuoteCoin,
+		TickSize:    contract.PriceUnit,
+		StepSize:    contract.VolUnit * contract.ContractSize,
+		MinQuantity: contract.MinVol * contract.ContractSize,
+		MaxLeverage: contract.MaxLeverage,
+	}, nil
+}
+
+// getContract returns contract details, they are loaded once and kept in memory
+func (m *MexcFutures) getContract(ctx context.Context, symbol, baseSymbol string) (*contractMexcFutures, error) {
+	contractSymbol := mexcFuturesSymbol(symbol, baseSymbol)
+
+	m.mu.RLock()
+	contract, ok := m.contracts[contractSymbol]
+	m.mu.RUnlock()
+	if ok {
+		return contract, nil
+	}
+
+	queryParams := url.Values{}
+	queryParams.Set("symbol", contractSymbol)
+
+	contract = &contractMexcFutures{}
+	if err := m.doRequest(ctx, http.MethodGet, "/api/v1/contract/detail", queryParams, nil, contract); err != nil {
+		return nil, fmt.Errorf("getContract : %w", err)
+	}
+	if contract.Symbol != contractSymbol || contract.ContractSize <= 0 {
+		return nil, ErrMexcFuturesSymbolNotFound
+	}
+
+	m.mu.Lock()
+	m.contracts[contractSymbol] = contract
+	m.mu.Unlock()
+
+	return contract, nil
+}
+
+// doRequest sends request through rate limiter. Idempotent requests, GET ones and orders with
+// external order id, are retried with backoff on network errors, rate limits and 5xx responses.
+func (m *MexcFutures) doRequest(ctx context.Context, method, path string, queryParams url.Values, body any, result any) error {
+	var rawBody []byte
+
+	retry := method != http.MethodPost
+	if body != nil {
+		var err error
+		if rawBody, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("doRequest : %w", err)
+		}
+
+		if order, ok := body.(*orderCreateMexcFutures); ok && order.ExternalOid != "" {
+			retry = true
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := m.limiter.Wait(ctx, 1); err != nil {
+			return fmt.Errorf("doRequest : %w", err)
+		}
+
+		data, retryAfter, err := m.send(ctx, method, path, queryParams, rawBody)
+		if err == nil {
+			if result == nil {
+				return nil
+			}
+			if err := json.Unmarshal(data, result); err != nil {
+				return fmt.Errorf("doRequest : %w", err)
+			}
+
+			return nil
+		}
+
+		if !retry || retryAfter < 0 || attempt >= m.maxRetries {
+			return err
+		}
+
+		delay := mexcRetryBaseDelay << attempt
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("doRequest : %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes a single request signed with api key, request time and payload, that is sorted
+// query for GET requests or JSON body for POST requests. Negative retryAfter means request
+// must not be repeated.
+func (m *MexcFutures) send(ctx context.Context, method, path string, queryParams url.Values, rawBody []byte) (json.RawMessage, time.Duration, error) {
+	var (
+		payload     string
+		requestBody io.Reader
+	)
+
+	requestURL := m.baseUrl + path
+	if len(queryParams) > 0 {
+		payload = queryParams.Encode()
+		requestURL = fmt.Sprintf("%s?%s", requestURL, payload)
+	}
+	if rawBody != nil {
+		payload = string(rawBody)
+		requestBody = bytes.NewReader(rawBody)
+	}
+
+	requestTime := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	mac := hmac.New(sha256.New, []byte(m.secretKey))
+	mac.Write([]byte(m.apiKey + requestTime + payload))
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, requestBody)
+	if err != nil {
+		return nil, -1, fmt.Errorf("doRequest : %w", err)
+	}
+	req.Header.Set("ApiKey", m.apiKey)
+	req.Header.Set("Request-Time", requestTime)
+	req.Header.Set("Signature", hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, fmt.Errorf("error sending request: %w", ctx.Err())
+		}
+
+		return nil, 0, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading response : %w", err)
+	}
+
+	var response mexcFuturesResponse
+	if resp.StatusCode >= 400 && resp.StatusCode < 600 {
+		futuresErr := &MexcFuturesError{StatusCode: resp.StatusCode, Message: string(respBody)}
+		if err := json.Unmarshal(respBody, &response); err == nil && (response.Code != 0 || response.Message != "") {
+			futuresErr.Code = response.Code
+			futuresErr.Message = response.Message
+		}
+
+		return nil, mexcFuturesRetryAfter(resp, futuresErr), futuresErr
+	}
+
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, -1, fmt.Errorf("doRequest : %w", err)
+	}
+	if !response.Success || response.Code != 0 {
+		futuresErr := &MexcFuturesError{StatusCode: resp.StatusCode, Code: response.Code, Message: response.Message}
+
+		return nil, mexcFuturesRetryAfter(resp, futuresErr), futuresErr
+	}
+
+	return response.Data, 0, nil
+}
+
+// mexcFuturesRetryAfter returns how long to wait before failed request is repeated, negative when it must not be.
+// Only rate limited requests and server failures are repeated.
+func mexcFuturesRetryAfter(resp *http.Response, futuresErr *MexcFuturesError) time.Duration {
+	if futuresErr.Category() != ErrorCategoryRateLimit && resp.StatusCode < 500 {
+		return -1
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return 0
+}
+
+// contractVolume converts quantity in base symbol to contracts rounded down to volume unit of contract,
+// so exchange never gets float noise like 2.9999999999999996 or a part of volume unit
+func contractVolume(quantity float64, contract *contractMexcFutures) (float64, error) {
+	volUnit := contract.VolUnit
+	if volUnit <= 0 {
+		volUnit = 1
+	}
+
+	// small epsilon keeps values like 0.3/0.1 = 2.9999999999999996 on their unit
+	units := math.Floor(quantity/contract.ContractSize/volUnit + 1e-9)
+	if units <= 0 {
+		return 0, ErrMexcFuturesVolumeTooSmall
+	}
+
+	// multiple of unit is cut to digits unit has after point
+	precision := 0
+	formattedUnit := strconv.FormatFloat(volUnit, 'f', -1, 64)
+	if point := strings.IndexByte(formattedUnit, '.'); point >= 0 {
+		precision = len(formattedUnit) - point - 1
+	}
+
+	return strconv.ParseFloat(strconv.FormatFloat(units*volUnit, 'f', precision, 64), 64)
+}
+
+func mexcFuturesSymbol(symbol, baseSymbol string) string {
+	return fmt.Sprintf("%s_%s", symbol, baseSymbol)
+}
+
+func newOrderFromMexcFutures(orderRecv *orderMexcFutures, contract *contractMexcFutures) (*commonTypes.Order, error) {
+	var status commonTypes.OrderStatus
+	switch orderRecv.State {
+	case mexcFuturesStateUninformed, mexcFuturesStateUncompleted:
+		status = commonTypes.OrderStatusNew
+		if orderRecv.DealVol > 0 {
+			status = commonTypes.OrderStatusPartiallyFilled
+		}
+	case mexcFuturesStateCompleted:
+		status = commonTypes.OrderStatusFilled
+	case mexcFuturesStateCancelled, mexcFuturesStateInvalid:
+		status = commonTypes.OrderStatusCanceled
+		if orderRecv.DealVol > 0 {
+			status = commonTypes.OrderStatusPartiallyCanceled
+		}
+	default:
+		return nil, ErrMexcFuturesOrderStatusNotFound
+	}
+
+	var side commonTypes.OrderSide
+	switch orderRecv.Side {
+	case mexcFuturesOpenLong, mexcFuturesCloseShort:
+		side = commonTypes.OrderSideLong
+	case mexcFuturesOpenShort, mexcFuturesCloseLong:
+		side = commonTypes.OrderSideShort
+	default:
+		return nil, ErrMexcFuturesOrderSideNotFound
+	}
+
+	var orderType commonTypes.OrderType
+	for t, mexcType := range mexcFuturesOrderType {
+		if mexcType == orderRecv.OrderType {
+			orderType = t
+			break
+		}
+	}
+	if orderType == "" {
+		return nil, ErrMexcFuturesOrderTypeNotFound
+	}
+
+	return &commonTypes.Order{
+		OrderID:          orderRecv.OrderID.String(),
+		ClientOrderID:    orderRecv.ExternalOid,
+		Currency:         orderRecv.Symbol,
+		Side:             side,
+		Type:             orderType,
+		Quantity:         orderRecv.Vol * contract.ContractSize,
+		Price:            orderRecv.Price,
+		Status:           status,
+		ExecutedQuantity: orderRecv.DealVol * contract.ContractSize,
+		AveragePrice:     orderRecv.DealAvgPrice,
+		Fee:              orderRecv.TakerFee + orderRecv.MakerFee,
+	}, nil
+}
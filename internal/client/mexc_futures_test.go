@@ -0,0 +1,329 @@
+package client_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+const (
+	mexcFuturesTestKey    string = "key"
+	mexcFuturesTestSecret string = "secret"
+
+	mexcFuturesTestContract string = `{"success":true,"code":0,"data":{"symbol":"ETC_USDT","baseCoin":"ETC","quoteCoin":"USDT","contractSize":0.1,"priceUnit":0.001,"volUnit":1,"minVol":1,"maxLeverage":75}}`
+)
+
+func newMexcFuturesTestServer(t *testing.T, marginMode commonTypes.MarginMode, handlers map[string]func(t *testing.T, r *http.Request, body []byte) string) *client.MexcFutures {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		payload := r.URL.RawQuery
+		if r.Method == http.MethodPost {
+			payload = string(body)
+		}
+		mac := hmac.New(sha256.New, []byte(mexcFuturesTestSecret))
+		mac.Write([]byte(mexcFuturesTestKey + r.Header.Get("Request-Time") + payload))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get("Signature"))
+		assert.Equal(t, mexcFuturesTestKey, r.Header.Get("ApiKey"))
+
+		handler, ok := handlers[r.Method+" "+r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(handler(t, r, body)))
+	}))
+	t.Cleanup(server.Close)
+
+	return client.NewMexcFutures(&client.MexcFuturesOptions{
+		ApiKey:     mexcFuturesTestKey,
+		ApiSecret:  mexcFuturesTestSecret,
+		BaseURL:    server.URL,
+		MarginMode: marginMode,
+		HTTPClient: server.Client(),
+		MaxRetries: -1,
+	})
+}
+
+// newMexcFuturesRetryClient returns client with retries enabled, handler writes raw responses
+func newMexcFuturesRetryClient(t *testing.T, handler http.HandlerFunc) *client.MexcFutures {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return client.NewMexcFutures(&client.MexcFuturesOptions{
+		ApiKey:     mexcFuturesTestKey,
+		ApiSecret:  mexcFuturesTestSecret,
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+	})
+}
+
+func TestMexcFuturesCreateFuturesOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		position   commonTypes.Position
+		reduceOnly bool
+		side       float64
+	}{
+		{name: "open long", position: commonTypes.PositionLong, side: 1},
+		{name: "open short", position: commonTypes.PositionShort, side: 3},
+		{name: "close long", position: commonTypes.PositionLong, reduceOnly: true, side: 4},
+		{name: "close short", position: commonTypes.PositionShort, reduceOnly: true, side: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contractRequests := 0
+			mexc := newMexcFuturesTestServer(t, "", map[string]func(t *testing.T, r *http.Request, body []byte) string{
+				"GET /api/v1/contract/detail": func(t *testing.T, r *http.Request, _ []byte) string {
+					contractRequests++
+					assert.Equal(t, "ETC_USDT", r.URL.Query().Get("symbol"))
+
+					return mexcFuturesTestContract
+				},
+				"POST /api/v1/private/order/submit": func(t *testing.T, r *http.Request, body []byte) string {
+					var request map[string]any
+					require.NoError(t, json.Unmarshal(body, &request))
+
+					assert.Equal(t, "ETC_USDT", request["symbol"])
+					assert.Equal(t, tt.side, request["side"])
+					assert.Equal(t, 1.0, request["type"])
+					assert.Equal(t, 1.0, request["openType"])
+					assert.Equal(t, 25.0, request["vol"])
+					assert.Equal(t, 18.5, request["price"])
+					assert.Equal(t, 50.0, request["leverage"])
+					assert.Equal(t, 17.592, request["stopLossPrice"])
+					assert.Equal(t, 18.902, request["takeProfitPrice"])
+
+					return `{"success":true,"code":0,"data":739113577038255616}`
+				},
+			})
+
+			for range 2 {
+				orderID, err := mexc.CreateFuturesOrder(context.Background(), &types.FuturesOrder{
+					Type:       commonTypes.OrderTypeLimit,
+					Position:   tt.position,
+					Symbol:     "ETC",
+					BaseSymbol: "USDT",
+					Entry:      18.5,
+					Quantity:   2.5,
+					Leverage:   50,
+					StopLoss:   17.592,
+					TakeProfit: 18.902,
+					ReduceOnly: tt.reduceOnly,
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, commonTypes.OrderID("739113577038255616"), orderID)
+			}
+
+			// contract details are cached
+			assert.Equal(t, 1, contractRequests)
+		})
+	}
+}
+
+func TestMexcFuturesCreateFuturesOrderVolume(t *testing.T) {
+	tests := []struct {
+		name     string
+		contract string
+		quantity float64
+		vol      float64
+		err      error
+	}{
+		{"float noise", mexcFuturesTestContract, 0.3, 3, nil},
+		{"part of unit", mexcFuturesTestContract, 2.57, 25, nil},
+		{"fractional unit", `{"success":true,"code":0,"data":{"symbol":"ETC_USDT","baseCoin":"ETC","quoteCoin":"USDT","contractSize":1,"priceUnit":0.001,"volUnit":0.1,"minVol":0.1,"maxLeverage":75}}`, 0.37, 0.3, nil},
+		{"below unit", mexcFuturesTestContract, 0.05, 0, client.ErrMexcFuturesVolumeTooSmall},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var vol float64
+			mexc := newMexcFuturesTestServer(t, "", map[string]func(t *testing.T, r *http.Request, body []byte) string{
+				"GET /api/v1/contract/detail": func(t *testing.T, r *http.Request, _ []byte) string {
+					return test.contract
+				},
+				"POST /api/v1/private/order/submit": func(t *testing.T, r *http.Request, body []byte) string {
+					var request map[string]any
+					require.NoError(t, json.Unmarshal(body, &request))
+					vol = request["vol"].(float64)
+
+					return `{"success":true,"code":0,"data":42}`
+				},
+			})
+
+			_, err := mexc.CreateFuturesOrder(context.Background(), &types.FuturesOrder{
+				Type:       commonTypes.OrderTypeMarket,
+				Position:   commonTypes.PositionLong,
+				Symbol:     "ETC",
+				BaseSymbol: "USDT",
+				Quantity:   test.quantity,
+			})
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.vol, vol)
+		})
+	}
+}
+
+func TestMexcFuturesSetLeverage(t *testing.T) {
+	var positionTypes []float64
+	mexc := newMexcFuturesTestServer(t, commonTypes.MarginModeCross, map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"POST /api/v1/private/position/change_leverage": func(t *testing.T, r *http.Request, body []byte) string {
+			var request map[string]any
+			require.NoError(t, json.Unmarshal(body, &request))
+
+			assert.Equal(t, "ETC_USDT", request["symbol"])
+			assert.Equal(t, 25.0, request["leverage"])
+			assert.Equal(t, 2.0, request["openType"])
+			positionTypes = append(positionTypes, request["positionType"].(float64))
+
+			return `{"success":true,"code":0}`
+		},
+	})
+
+	assert.NoError(t, mexc.SetLeverage(context.Background(), "ETC", "USDT", 25))
+	assert.Equal(t, []float64{1, 2}, positionTypes)
+}
+
+func TestMexcFuturesGetOrder(t *testing.T) {
+	mexc := newMexcFuturesTestServer(t, "", map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /api/v1/contract/detail": func(t *testing.T, r *http.Request, _ []byte) string {
+			return mexcFuturesTestContract
+		},
+		"GET /api/v1/private/order/get/42": func(t *testing.T, r *http.Request, _ []byte) string {
+			return `{"success":true,"code":0,"data":{"orderId":"42","symbol":"ETC_USDT","price":18.5,"vol":25,"side":3,"orderType":1,"dealAvgPrice":18.5,"dealVol":10,"takerFee":0,"makerFee":0.01,"state":4}}`
+		},
+	})
+
+	order, err := mexc.GetOrder(context.Background(), "ETC", "USDT", "42")
+	require.NoError(t, err)
+	assert.Equal(t, &commonTypes.Order{
+		OrderID:          "42",
+		Currency:         "ETC_USDT",
+		Side:             commonTypes.OrderSideShort,
+		Type:             commonTypes.OrderTypeLimit,
+		Quantity:         2.5,
+		Price:            18.5,
+		Status:           commonTypes.OrderStatusPartiallyCanceled,
+		ExecutedQuantity: 1,
+		AveragePrice:     18.5,
+		Fee:              0.01,
+	}, order)
+}
+
+func TestMexcFuturesApiError(t *testing.T) {
+	mexc := newMexcFuturesTestServer(t, "", map[string]func(t *testing.T, r *http.Request, body []byte) string{
+		"GET /api/v1/private/account/asset/USDT": func(t *testing.T, r *http.Request, _ []byte) string {
+			return `{"success":false,"code":602,"message":"Signature verification failed!"}`
+		},
+	})
+
+	_, err := mexc.GetAssets(context.Background(), "USDT")
+	assert.ErrorContains(t, err, "602")
+}
+
+func TestMexcFuturesRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	mexc := newMexcFuturesRetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"success":true,"code":0,"data":{"currency":"USDT","availableBalance":25.5}}`))
+	})
+
+	balance, err := mexc.GetAssets(context.Background(), "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, 25.5, balance)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestMexcFuturesDoesNotRetryOrderWithoutClientID(t *testing.T) {
+	var calls atomic.Int32
+	mexc := newMexcFuturesRetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/contract/detail" {
+			_, _ = w.Write([]byte(mexcFuturesTestContract))
+			return
+		}
+
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := mexc.CreateFuturesOrder(context.Background(), &types.FuturesOrder{
+		Type:       commonTypes.OrderTypeLimit,
+		Position:   commonTypes.PositionLong,
+		Symbol:     "ETC",
+		BaseSymbol: "USDT",
+		Entry:      18.5,
+		Quantity:   2.5,
+		Leverage:   50,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMexcFuturesTypedHTTPError(t *testing.T) {
+	var calls atomic.Int32
+	mexc := newMexcFuturesRetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("Access denied"))
+	})
+
+	_, err := mexc.GetAssets(context.Background(), "USDT")
+
+	var futuresErr *client.MexcFuturesError
+	require.ErrorAs(t, err, &futuresErr)
+	assert.Equal(t, http.StatusForbidden, futuresErr.StatusCode)
+	assert.Equal(t, "Access denied", futuresErr.Message)
+	assert.Equal(t, client.ErrorCategoryAuth, client.CategoryOf(err))
+	// auth errors are not repeated
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMexcFuturesErrorCategory(t *testing.T) {
+	tests := []struct {
+		code     int
+		category client.ErrorCategory
+	}{
+		{602, client.ErrorCategoryAuth},
+		{2005, client.ErrorCategoryBalance},
+		{1001, client.ErrorCategorySymbol},
+		{510, client.ErrorCategoryRateLimit},
+		{513, client.ErrorCategoryTimestamp},
+		{9999, client.ErrorCategoryUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.code), func(t *testing.T) {
+			mexc := newMexcFuturesTestServer(t, "", map[string]func(t *testing.T, r *http.Request, body []byte) string{
+				"GET /api/v1/private/account/asset/USDT": func(t *testing.T, r *http.Request, _ []byte) string {
+					return fmt.Sprintf(`{"success":false,"code":%d,"message":"error"}`, test.code)
+				},
+			})
+
+			_, err := mexc.GetAssets(context.Background(), "USDT")
+			assert.Equal(t, test.category, client.CategoryOf(err))
+		})
+	}
+}
@@ -0,0 +1,483 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// newMexcTestClient returns client of test server, which serves time and checks request is signed
+func newMexcTestClient(t *testing.T, handler http.HandlerFunc) *client.Mexc {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/time" {
+			_, _ = fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().UnixMilli())
+			return
+		}
+
+		assert.Equal(t, "key", r.Header.Get("X-MEXC-APIKEY"))
+		query := r.URL.Query()
+		signature := query.Get("signature")
+		query.Del("signature")
+		assert.Equal(t, client.NewHMACSigner("secret").Sign(query.Encode()), signature)
+
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return client.NewMexc(&client.MexcOptions{
+		ApiKey:    "key",
+		ApiSecret: "secret",
+		BaseURL:   server.URL,
+	})
+}
+
+func TestMexcCreateSpotOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order *types.SpotOrder
+		query url.Values
+	}{
+		{
+			name: "limit buy",
+			order: &types.SpotOrder{
+				Type:          commonTypes.OrderTypeLimit,
+				Position:      commonTypes.PositionLong,
+				Symbol:        "ETC",
+				BaseSymbol:    "USDT",
+				Entry:         18.5,
+				Quantity:      1.25,
+				ClientOrderID: "signal-0",
+			},
+			query: url.Values{
+				"symbol":           {"ETCUSDT"},
+				"side":             {"BUY"},
+				"type":             {"LIMIT"},
+				"quantity":         {"1.25"},
+				"price":            {"18.5"},
+				"newClientOrderId": {"signal-0"},
+			},
+		},
+		{
+			name: "market sell",
+			order: &types.SpotOrder{
+				Type:       commonTypes.OrderTypeMarket,
+				Position:   commonTypes.PositionShort,
+				Symbol:     "ETC",
+				BaseSymbol: "USDT",
+				Entry:      18.5,
+				Quantity:   0.00001,
+			},
+			query: url.Values{
+				"symbol":   {"ETCUSDT"},
+				"side":     {"SELL"},
+				"type":     {"MARKET"},
+				"quantity": {"0.00001"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/api/v3/order", r.URL.Path)
+				for key := range test.query {
+					assert.Equal(t, test.query.Get(key), r.URL.Query().Get(key), key)
+				}
+				if _, ok := test.query["price"]; !ok {
+					assert.False(t, r.URL.Query().Has("price"))
+				}
+
+				_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","orderId":"C02__443776347957968896","price":"18.5"}`))
+			})
+
+			orderID, err := mexc.CreateSpotOrder(context.Background(), test.order)
+			require.NoError(t, err)
+			assert.Equal(t, commonTypes.OrderID("C02__443776347957968896"), orderID)
+		})
+	}
+}
+
+func TestMexcCancelAllOrders(t *testing.T) {
+	var calls atomic.Int32
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/v3/openOrders", r.URL.Path)
+		assert.Equal(t, "ETCUSDT", r.URL.Query().Get("symbol"))
+
+		_, _ = w.Write([]byte(`[{"symbol":"ETCUSDT","orderId":"1","status":"CANCELED"}]`))
+	})
+
+	require.NoError(t, mexc.CancelAllOrders(context.Background(), "ETC", "USDT"))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMexcGetPrice(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v3/ticker/price", r.URL.Path)
+		assert.Equal(t, "ETCUSDT", r.URL.Query().Get("symbol"))
+
+		_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","price":"18.715"}`))
+	})
+
+	price, err := mexc.GetPrice(context.Background(), "ETC", "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, 18.715, price)
+}
+
+func TestMexcGetAssets(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v3/account", r.URL.Path)
+
+		_, _ = w.Write([]byte(`{"canTrade":true,"balances":[` +
+			`{"asset":"USDT","free":"120.5","locked":"10"},` +
+			`{"asset":"ETC","free":"0.25","locked":"0"}]}`))
+	})
+
+	free, err := mexc.GetAssets(context.Background(), "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, 120.5, free)
+
+	free, err = mexc.GetAssets(context.Background(), "ETC")
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, free)
+
+	free, err = mexc.GetAssets(context.Background(), "BTC")
+	require.NoError(t, err, "asset account doesn't hold has zero balance")
+	assert.Equal(t, 0.0, free)
+}
+
+func TestMexcGetPortfolio(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/account":
+			_, _ = w.Write([]byte(`{"canTrade":true,"balances":[` +
+				`{"asset":"USDT","free":"100","locked":"20"},` +
+				`{"asset":"ETC","free":"2","locked":"0.5"},` +
+				`{"asset":"EUR","free":"10","locked":"0"},` +
+				`{"asset":"XYZ","free":"1000","locked":"0"}]}`))
+		case "/api/v3/ticker/price":
+			assert.False(t, r.URL.Query().Has("symbol"), "prices of all symbols are read at once")
+			_, _ = w.Write([]byte(`[{"symbol":"ETCUSDT","price":"20"},{"symbol":"USDTEUR","price":"0.5"}]`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	portfolio, err := mexc.GetPortfolio(context.Background(), "USDT")
+	require.NoError(t, err)
+	require.Len(t, portfolio.Balances, 4)
+
+	assert.Equal(t, &types.Balance{Asset: "USDT", Free: 100, Locked: 20, Value: 120}, portfolio.Balance("USDT"))
+	assert.Equal(t, &types.Balance{Asset: "ETC", Free: 2, Locked: 0.5, Value: 50}, portfolio.Balance("ETC"))
+	assert.Equal(t, 20.0, portfolio.Balance("EUR").Value, "asset is valued by inverse pair")
+	assert.Equal(t, 0.0, portfolio.Balance("XYZ").Value, "asset without price has no value")
+	assert.Equal(t, &types.Balance{Asset: "BTC"}, portfolio.Balance("BTC"))
+	assert.Equal(t, 190.0, portfolio.Value)
+}
+
+type mexcTestSigner struct{}
+
+func (mexcTestSigner) Sign(payload string) string {
+	return "signed"
+}
+
+func TestMexcUsesClockAndSigner(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/time" {
+			_, _ = fmt.Fprintf(w, `{"serverTime":%d}`, now.UnixMilli())
+			return
+		}
+
+		assert.Equal(t, "signed", r.URL.Query().Get("signature"))
+		assert.Equal(t, strconv.FormatInt(now.UnixMilli(), 10), r.URL.Query().Get("timestamp"))
+		_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","price":"18.715"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	mexc := client.NewMexc(&client.MexcOptions{
+		ApiKey:    "key",
+		BaseURL:   server.URL,
+		Transport: http.DefaultTransport,
+		Clock:     func() time.Time { return now },
+		Signer:    mexcTestSigner{},
+	})
+
+	_, err := mexc.GetPrice(context.Background(), "ETC", "USDT")
+	require.NoError(t, err)
+}
+
+func TestMexcRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","price":"18.715"}`))
+	})
+
+	price, err := mexc.GetPrice(context.Background(), "ETC", "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, 18.715, price)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestMexcDoesNotRetryOrderWithoutClientID(t *testing.T) {
+	var calls atomic.Int32
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := mexc.CreateSpotOrder(context.Background(), &types.SpotOrder{
+		Type:       commonTypes.OrderTypeLimit,
+		Position:   commonTypes.PositionLong,
+		Symbol:     "ETC",
+		BaseSymbol: "USDT",
+		Entry:      18.5,
+		Quantity:   1,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMexcStopsOnContextCancel(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := mexc.GetPrice(ctx, "ETC", "USDT")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), time.Second)
+}
+
+func TestMexcTypedError(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":10101,"msg":"Insufficient balance"}`))
+	})
+
+	_, err := mexc.CreateSpotOrder(context.Background(), &types.SpotOrder{
+		Type:       commonTypes.OrderTypeLimit,
+		Position:   commonTypes.PositionLong,
+		Symbol:     "ETC",
+		BaseSymbol: "USDT",
+		Entry:      18.5,
+		Quantity:   1,
+	})
+
+	var mexcErr *client.MexcError
+	require.ErrorAs(t, err, &mexcErr)
+	assert.Equal(t, 10101, mexcErr.Code)
+	assert.Equal(t, "Insufficient balance", mexcErr.Message)
+	assert.Equal(t, client.ErrorCategoryBalance, client.CategoryOf(err))
+}
+
+func TestMexcErrorCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *client.MexcError
+		category client.ErrorCategory
+	}{
+		{"signature", &client.MexcError{StatusCode: 400, Code: 700002}, client.ErrorCategoryAuth},
+		{"symbol", &client.MexcError{StatusCode: 400, Code: -1121}, client.ErrorCategorySymbol},
+		{"timestamp", &client.MexcError{StatusCode: 400, Code: 700003}, client.ErrorCategoryTimestamp},
+		{"rate limit by status", &client.MexcError{StatusCode: 429}, client.ErrorCategoryRateLimit},
+		{"forbidden", &client.MexcError{StatusCode: 403}, client.ErrorCategoryAuth},
+		{"unknown", &client.MexcError{StatusCode: 400, Code: 1}, client.ErrorCategoryUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.category, client.CategoryOf(fmt.Errorf("wrapped : %w", test.err)))
+		})
+	}
+}
+
+func TestMexcSignsWithServerTime(t *testing.T) {
+	drift := time.Minute
+	var timeCalls, orderCalls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/time" {
+			timeCalls.Add(1)
+			_, _ = fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().Add(drift).UnixMilli())
+			return
+		}
+
+		assert.Equal(t, "10000", r.URL.Query().Get("recvWindow"))
+		timestamp, err := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+		require.NoError(t, err)
+
+		// the first order is rejected as if exchange clock jumped after the sync
+		if orderCalls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":700003,"msg":"Timestamp for this request is outside of the recvWindow."}`))
+			return
+		}
+
+		assert.InDelta(t, time.Now().Add(drift).UnixMilli(), timestamp, float64(time.Second.Milliseconds()))
+		_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","orderId":"42","price":"18.5"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	mexc := client.NewMexc(&client.MexcOptions{
+		ApiKey:     "key",
+		ApiSecret:  "secret",
+		BaseURL:    server.URL,
+		RecvWindow: 10 * time.Second,
+	})
+
+	orderID, err := mexc.CreateSpotOrder(context.Background(), &types.SpotOrder{
+		Type:       commonTypes.OrderTypeLimit,
+		Position:   commonTypes.PositionLong,
+		Symbol:     "ETC",
+		BaseSymbol: "USDT",
+		Entry:      18.5,
+		Quantity:   1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commonTypes.OrderID("42"), orderID)
+	assert.Equal(t, int32(2), orderCalls.Load())
+	assert.Equal(t, int32(2), timeCalls.Load(), "clock is synced on the first request and again after timestamp error")
+}
+
+func TestMexcBacksOffFailedTimeSync(t *testing.T) {
+	var timeCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/time" {
+			timeCalls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","price":"18.715"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	mexc := client.NewMexc(&client.MexcOptions{
+		ApiKey:    "key",
+		ApiSecret: "secret",
+		BaseURL:   server.URL,
+	})
+
+	// requests are signed with local clock while exchange clock can't be read
+	for i := 0; i < 3; i++ {
+		_, err := mexc.GetPrice(context.Background(), "ETC", "USDT")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), timeCalls.Load())
+}
+
+func TestMexcCancelOrder(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/v3/order", r.URL.Path)
+		assert.Equal(t, "ETCUSDT", r.URL.Query().Get("symbol"))
+		assert.Equal(t, "42", r.URL.Query().Get("orderId"))
+
+		_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","orderId":"42","status":"CANCELED"}`))
+	})
+
+	require.NoError(t, mexc.CancelOrder(context.Background(), "ETC", "USDT", "42"))
+}
+
+func TestMexcGetOrder(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		switch r.URL.Path {
+		case "/api/v3/order":
+			assert.Equal(t, "42", r.URL.Query().Get("orderId"))
+			_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","orderId":"42","price":"18.5","origQty":"2","executedQty":"1",` +
+				`"cummulativeQuoteQty":"18.4","status":"PARTIALLY_FILLED","type":"LIMIT","side":"BUY"}`))
+		case "/api/v3/myTrades":
+			_, _ = w.Write([]byte(`[{"orderId":"42","price":"18.4","qty":"0.5","commission":"0.01","commissionAsset":"USDT"},` +
+				`{"orderId":"42","price":"18.4","qty":"0.3","commission":"0.002","commissionAsset":"ETC"},` +
+				`{"orderId":"42","price":"18.4","qty":"0.2","commission":"0.05","commissionAsset":"MX"}]`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	order, err := mexc.GetOrder(context.Background(), "ETC", "USDT", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "42", order.OrderID)
+	assert.Equal(t, commonTypes.OrderStatusPartiallyFilled, order.Status)
+	assert.Equal(t, commonTypes.OrderTypeLimit, order.Type)
+	assert.Equal(t, 2.0, order.Quantity)
+	assert.Equal(t, 1.0, order.ExecutedQuantity)
+	assert.Equal(t, 18.4, order.AveragePrice)
+	assert.Equal(t, 0.01, order.Fee)
+	assert.Equal(t, 0.002, order.QuantityFee)
+}
+
+func TestMexcGetSymbolInfo(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/exchangeInfo", r.URL.Path)
+
+		_, _ = w.Write([]byte(`{"symbols":[{"symbol":"ETCUSDT","baseAsset":"ETC","quoteAsset":"USDT",` +
+			`"baseAssetPrecision":2,"quotePrecision":3,"baseSizePrecision":"0.1","quoteAmountPrecision":"5"}]}`))
+	})
+
+	info, err := mexc.GetSymbolInfo(context.Background(), "ETC", "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, &types.SymbolInfo{
+		Symbol:      "ETC",
+		BaseSymbol:  "USDT",
+		TickSize:    0.001,
+		StepSize:    0.1,
+		MinQuantity: 0.1,
+		MinNotional: 5,
+	}, info)
+
+	_, err = mexc.GetSymbolInfo(context.Background(), "BTC", "USDT")
+	assert.ErrorIs(t, err, client.ErrMexcSymbolNotFound)
+}
+
+func TestMexcGetOrderByClientID(t *testing.T) {
+	mexc := newMexcTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/order", r.URL.Path)
+
+		if r.URL.Query().Get("origClientOrderId") != "signal-0" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":-2013,"msg":"Order does not exist."}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"symbol":"ETCUSDT","orderId":"42","clientOrderId":"signal-0","price":"18.5",` +
+			`"origQty":"1","executedQty":"0","cummulativeQuoteQty":"0","status":"NEW","type":"LIMIT","side":"BUY"}`))
+	})
+
+	order, err := mexc.GetOrderByClientID(context.Background(), "ETC", "USDT", "signal-0")
+	require.NoError(t, err)
+	assert.Equal(t, "42", order.OrderID)
+	assert.Equal(t, "signal-0", order.ClientOrderID)
+
+	_, err = mexc.GetOrderByClientID(context.Background(), "ETC", "USDT", "signal-1")
+	assert.ErrorIs(t, err, client.ErrOrderNotFound)
+}
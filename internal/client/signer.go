@@ -0,0 +1,30 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Signer signs payload of private request
+type Signer interface {
+	Sign(payload string) string
+}
+
+// HMACSigner signs payload with hex encoded HMAC-SHA256 of api secret
+type HMACSigner struct {
+	secret []byte
+}
+
+func NewHMACSigner(secret string) *HMACSigner {
+	return &HMACSigner{
+		secret: []byte(secret),
+	}
+}
+
+func (h *HMACSigner) Sign(payload string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(payload))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
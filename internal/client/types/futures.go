@@ -0,0 +1,35 @@
+package types
+
+import (
+	commonTypes "trade_bot/internal/types"
+)
+
+// FuturesOrder opens a position or, with ReduceOnly, closes the Position given
+type FuturesOrder struct {
+	Exchange   commonTypes.Exchange
+	Type       commonTypes.OrderType
+	Position   commonTypes.Position
+	Symbol     string
+	BaseSymbol string
+	Entry      float64
+	Quantity   float64
+	Leverage   float64
+	MarginMode commonTypes.MarginMode
+	StopLoss   float64
+	TakeProfit float64
+	ReduceOnly bool
+	// ClientOrderID is kept by exchange with the order, placing order with the same id twice is rejected
+	ClientOrderID string
+}
+
+// OpenPosition is a position held on futures exchange
+type OpenPosition struct {
+	Symbol           string
+	BaseSymbol       string
+	Position         commonTypes.Position
+	Quantity         float64
+	Entry            float64
+	Leverage         float64
+	LiquidationPrice float64
+	UnrealisedPnl    float64
+}
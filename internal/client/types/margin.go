@@ -0,0 +1,9 @@
+package types
+
+// MarginTier holds maintenance margin of positions worth up to MaxValue of quote symbol.
+// Maintenance margin of position is its value times MaintenanceRate minus MaintenanceDeduction.
+type MarginTier struct {
+	MaxValue             float64
+	MaintenanceRate      float64
+	MaintenanceDeduction float64
+}
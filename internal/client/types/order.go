@@ -12,4 +12,6 @@ type SpotOrder struct {
 	BaseSymbol string
 	Entry      float64
 	Quantity   float64
+	// ClientOrderID is kept by exchange with the order, placing order with the same id twice is rejected
+	ClientOrderID string
 }
@@ -0,0 +1,34 @@
+package types
+
+// Balance is amount of asset held on exchange account
+type Balance struct {
+	Asset  string
+	Free   float64
+	Locked float64
+	// Value is worth of the whole amount in quote symbol of portfolio, zero when asset has no price in it
+	Value float64
+}
+
+// Total returns free and locked amount together
+func (b *Balance) Total() float64 {
+	return b.Free + b.Locked
+}
+
+// Portfolio holds every asset of exchange account valued in quote symbol (e.g. USDT)
+type Portfolio struct {
+	QuoteSymbol string
+	Balances    []*Balance
+	// Value is the sum of values of balances
+	Value float64
+}
+
+// Balance returns balance of asset, asset account doesn't hold has zero balance
+func (p *Portfolio) Balance(asset string) *Balance {
+	for _, balance := range p.Balances {
+		if balance.Asset == asset {
+			return balance
+		}
+	}
+
+	return &Balance{Asset: asset}
+}
@@ -0,0 +1,13 @@
+package types
+
+// SymbolInfo holds trading rules of a symbol on exchange
+type SymbolInfo struct {
+	Symbol      string
+	BaseSymbol  string
+	TickSize    float64
+	StepSize    float64
+	MinQuantity float64
+	MinNotional float64
+	// MaxLeverage is the highest leverage exchange allows for symbol, zero when unknown or not leveraged
+	MaxLeverage float64
+}
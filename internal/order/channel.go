@@ -0,0 +1,67 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// Sizer decides quantity of symbol to trade for signal at given entry price and leverage
+type Sizer interface {
+	Size(ctx context.Context, exchangeClient client.Client, signal *signalTypes.Signal, entry, leverage float64) (float64, error)
+}
+
+// EntryPlanner splits position quantity into limit orders inside signal entry interval
+type EntryPlanner interface {
+	Plan(position commonTypes.Position, interval *commonTypes.Interval, quantity float64) ([]*types.EntryLeg, error)
+}
+
+// StopRule proposes a new stop of open position, zero means rule doesn't move stop at the moment.
+// Manager takes proposed stop only if it is tighter than the current one.
+type StopRule interface {
+	Stop(ctx context.Context, exchangeClient client.Client, position *types.Order, now time.Time) (float64, error)
+}
+
+// LeveragePolicy picks leverage of futures position for signal entered at entry price
+type LeveragePolicy interface {
+	Leverage(signal *signalTypes.Signal, entry float64) (float64, error)
+}
+
+// EntryPolicy decides what is done with signal when price already left its entry interval
+type EntryPolicy string
+
+const (
+	// EntryPolicySkip rejects signal
+	EntryPolicySkip EntryPolicy = "skip"
+	// EntryPolicyWait places limit legs inside interval waiting for price to come back
+	EntryPolicyWait EntryPolicy = "wait"
+	// EntryPolicyMarket enters at market while price is within tolerance of interval, signal is rejected otherwise
+	EntryPolicyMarket EntryPolicy = "market"
+)
+
+// SlippageOptions holds how live price is checked against signal before position is placed
+type SlippageOptions struct {
+	Policy EntryPolicy
+	// Tolerance is how far price may be from the nearest edge of interval for market entry, in percent of the edge
+	Tolerance float64
+}
+
+// ChannelOptions holds how signals of a channel are executed
+type ChannelOptions struct {
+	Sizer Sizer
+	// EntryPlanner is optional, position is entered with a single order in the middle of entry interval without it
+	EntryPlanner EntryPlanner
+	// StopRules are optional, stop stays where signal put it without them
+	StopRules []StopRule
+	// Slippage is optional, signal is placed whatever the price is without it
+	Slippage *SlippageOptions
+	// Leverage is optional, the lowest leverage suggested by signal is taken without it
+	Leverage LeveragePolicy
+	// EntryTTL is how long entry legs wait for fill before they are cancelled, zero keeps them until
+	// price reaches target or stop
+	EntryTTL time.Duration
+}
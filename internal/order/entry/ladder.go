@@ -0,0 +1,72 @@
+package entry
+
+import (
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type Distribution string
+
+const (
+	// DistributionLinear puts the same quantity on every leg
+	DistributionLinear Distribution = "linear"
+	// DistributionWeighted puts more quantity on legs with better price
+	DistributionWeighted Distribution = "weighted"
+)
+
+// Ladder spreads position into evenly spaced limit orders across entry interval.
+// Legs are ordered from the worst price, which is filled first, to the best one.
+type Ladder struct {
+	legs         int
+	distribution Distribution
+}
+
+func NewLadder(legs int, distribution Distribution) *Ladder {
+	return &Ladder{
+		legs:         legs,
+		distribution: distribution,
+	}
+}
+
+func (l *Ladder) Plan(position commonTypes.Position, interval *commonTypes.Interval, quantity float64) ([]*types.EntryLeg, error) {
+	if interval == nil {
+		return nil, types.ErrOrderEntryNotFound
+	}
+	if l.legs < 1 || quantity <= 0 {
+		return nil, types.ErrOrderLegsInvalid
+	}
+
+	if l.legs == 1 {
+		return []*types.EntryLeg{{
+			Price:    (interval.Min + interval.Max) / 2,
+			Quantity: quantity,
+		}}, nil
+	}
+
+	// long is better entered lower, short is better entered higher
+	worst, best := interval.Max, interval.Min
+	if position == commonTypes.PositionShort {
+		worst, best = interval.Min, interval.Max
+	}
+	step := (best - worst) / float64(l.legs-1)
+
+	weights := make([]float64, l.legs)
+	totalWeight := 0.0
+	for i := range weights {
+		weights[i] = 1
+		if l.distribution == DistributionWeighted {
+			weights[i] = float64(i + 1)
+		}
+		totalWeight += weights[i]
+	}
+
+	legs := make([]*types.EntryLeg, 0, l.legs)
+	for i := range l.legs {
+		legs = append(legs, &types.EntryLeg{
+			Price:    worst + step*float64(i),
+			Quantity: quantity * weights[i] / totalWeight,
+		})
+	}
+
+	return legs, nil
+}
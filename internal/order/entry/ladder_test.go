@@ -0,0 +1,54 @@
+package entry_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/order/entry"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+func TestLadderLinearLong(t *testing.T) {
+	legs, err := entry.NewLadder(3, entry.DistributionLinear).
+		Plan(commonTypes.PositionLong, commonTypes.NewInterval(10, 12), 9)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*types.EntryLeg{
+		{Price: 12, Quantity: 3},
+		{Price: 11, Quantity: 3},
+		{Price: 10, Quantity: 3},
+	}, legs)
+}
+
+func TestLadderWeightedShort(t *testing.T) {
+	legs, err := entry.NewLadder(3, entry.DistributionWeighted).
+		Plan(commonTypes.PositionShort, commonTypes.NewInterval(10, 12), 12)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*types.EntryLeg{
+		{Price: 10, Quantity: 2},
+		{Price: 11, Quantity: 4},
+		{Price: 12, Quantity: 6},
+	}, legs)
+}
+
+func TestLadderSingleLeg(t *testing.T) {
+	legs, err := entry.NewLadder(1, entry.DistributionWeighted).
+		Plan(commonTypes.PositionLong, commonTypes.NewInterval(10, 12), 5)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*types.EntryLeg{{Price: 11, Quantity: 5}}, legs)
+}
+
+func TestLadderInvalid(t *testing.T) {
+	_, err := entry.NewLadder(0, entry.DistributionLinear).
+		Plan(commonTypes.PositionLong, commonTypes.NewInterval(10, 12), 5)
+	assert.ErrorIs(t, err, types.ErrOrderLegsInvalid)
+
+	_, err = entry.NewLadder(3, entry.DistributionLinear).
+		Plan(commonTypes.PositionLong, nil, 5)
+	assert.ErrorIs(t, err, types.ErrOrderEntryNotFound)
+}
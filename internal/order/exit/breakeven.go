@@ -0,0 +1,38 @@
+package exit
+
+import (
+	"context"
+	"time"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// BreakEven moves stop to average fill price once position took the given number of targets
+type BreakEven struct {
+	targets int
+}
+
+func NewBreakEven(targets int) *BreakEven {
+	return &BreakEven{
+		targets: targets,
+	}
+}
+
+func (b *BreakEven) Stop(_ context.Context, _ client.Client, position *types.Order, _ time.Time) (float64, error) {
+	if b.targets < 1 || position.TargetsHit < b.targets {
+		return 0, nil
+	}
+
+	return position.EntryPrice(), nil
+}
+
+// behind returns price moved by distance against position
+func behind(position *types.Order, price, distance float64) float64 {
+	if position.Position == commonTypes.PositionShort {
+		return price + distance
+	}
+
+	return price - distance
+}
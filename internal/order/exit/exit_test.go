@@ -0,0 +1,101 @@
+package exit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/exit"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type candleClient struct {
+	client.Client
+	candles []*commonTypes.Candle
+}
+
+func (c *candleClient) GetCandles(_ context.Context, _, _ string, _ commonTypes.CandleInterval, _ int) ([]*commonTypes.Candle, error) {
+	return c.candles, nil
+}
+
+func TestBreakEven(t *testing.T) {
+	position := &types.Order{Position: commonTypes.PositionLong, Entry: 20, TargetsHit: 0}
+
+	stop, err := exit.NewBreakEven(1).Stop(context.Background(), nil, position, time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, stop)
+
+	position.TargetsHit = 1
+	stop, err = exit.NewBreakEven(1).Stop(context.Background(), nil, position, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, stop)
+
+	// break-even is where position is really filled
+	position.AveragePrice = 19.5
+	stop, err = exit.NewBreakEven(1).Stop(context.Background(), nil, position, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 19.5, stop)
+}
+
+func TestTrailingPercent(t *testing.T) {
+	long := &types.Order{Position: commonTypes.PositionLong, Entry: 20, BestPrice: 25}
+	stop, err := exit.NewTrailingPercent(10).Stop(context.Background(), nil, long, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 22.5, stop)
+
+	short := &types.Order{Position: commonTypes.PositionShort, Entry: 20, BestPrice: 15}
+	stop, err = exit.NewTrailingPercent(10).Stop(context.Background(), nil, short, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 16.5, stop)
+
+	// no trailing before position is in profit
+	long.BestPrice = 19
+	stop, err = exit.NewTrailingPercent(10).Stop(context.Background(), nil, long, time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, stop)
+
+	// profit is measured from average fill price
+	long.BestPrice, long.AveragePrice = 21, 22
+	stop, err = exit.NewTrailingPercent(10).Stop(context.Background(), nil, long, time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, stop)
+}
+
+func TestTrailingATR(t *testing.T) {
+	position := &types.Order{Position: commonTypes.PositionLong, Entry: 20, BestPrice: 25}
+	exchangeClient := &candleClient{candles: []*commonTypes.Candle{
+		{High: 21, Low: 19, Close: 20},
+		{High: 22, Low: 20, Close: 21},
+		{High: 25, Low: 21, Close: 24},
+	}}
+
+	// true ranges are 2 and 4
+	stop, err := exit.NewTrailingATR(commonTypes.CandleInterval1h, 2, 1.5).Stop(context.Background(), exchangeClient, position, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 20.5, stop)
+
+	_, err = exit.NewTrailingATR(commonTypes.CandleInterval1h, 2, 1.5).Stop(context.Background(), &struct{ client.Client }{}, position, time.Now())
+	assert.ErrorIs(t, err, types.ErrOrderCandlesNotSupported)
+}
+
+func TestTighten(t *testing.T) {
+	now := time.Now()
+	position := &types.Order{Position: commonTypes.PositionLong, Entry: 20, CreatedAt: now.Add(-2 * time.Hour)}
+
+	stop, err := exit.NewTighten(3*time.Hour, 1).Stop(context.Background(), nil, position, now)
+	assert.NoError(t, err)
+	assert.Zero(t, stop)
+
+	stop, err = exit.NewTighten(time.Hour, 1).Stop(context.Background(), nil, position, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 19.8, stop)
+
+	position.AveragePrice = 10
+	stop, err = exit.NewTighten(time.Hour, 1).Stop(context.Background(), nil, position, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 9.9, stop)
+}
@@ -0,0 +1,32 @@
+package exit
+
+import (
+	"context"
+	"time"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+)
+
+// Tighten moves stop to percent behind average fill price once position stays open longer than after
+type Tighten struct {
+	after   time.Duration
+	percent float64
+}
+
+func NewTighten(after time.Duration, percent float64) *Tighten {
+	return &Tighten{
+		after:   after,
+		percent: percent,
+	}
+}
+
+func (t *Tighten) Stop(_ context.Context, _ client.Client, position *types.Order, now time.Time) (float64, error) {
+	if t.after <= 0 || t.percent < 0 || now.Sub(position.CreatedAt) < t.after {
+		return 0, nil
+	}
+
+	entry := position.EntryPrice()
+
+	return behind(position, entry, entry*t.percent/100), nil
+}
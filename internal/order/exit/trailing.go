@@ -0,0 +1,87 @@
+package exit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// TrailingPercent keeps stop percent behind the best price once position is in profit
+type TrailingPercent struct {
+	percent float64
+}
+
+func NewTrailingPercent(percent float64) *TrailingPercent {
+	return &TrailingPercent{
+		percent: percent,
+	}
+}
+
+func (t *TrailingPercent) Stop(_ context.Context, _ client.Client, position *types.Order, _ time.Time) (float64, error) {
+	if t.percent <= 0 || !inProfit(position) {
+		return 0, nil
+	}
+
+	return behind(position, position.BestPrice, position.BestPrice*t.percent/100), nil
+}
+
+// TrailingATR keeps stop multiplier of average true range behind the best price once position is in profit.
+// Range is measured on period candles of interval, exchange client has to be client.CandleClient.
+type TrailingATR struct {
+	interval   commonTypes.CandleInterval
+	period     int
+	multiplier float64
+}
+
+func NewTrailingATR(interval commonTypes.CandleInterval, period int, multiplier float64) *TrailingATR {
+	return &TrailingATR{
+		interval:   interval,
+		period:     period,
+		multiplier: multiplier,
+	}
+}
+
+func (t *TrailingATR) Stop(ctx context.Context, exchangeClient client.Client, position *types.Order, _ time.Time) (float64, error) {
+	if t.period < 1 || t.multiplier <= 0 || !inProfit(position) {
+		return 0, nil
+	}
+
+	candleClient, ok := exchangeClient.(client.CandleClient)
+	if !ok {
+		return 0, types.ErrOrderCandlesNotSupported
+	}
+
+	// true range of a candle needs close of the previous one
+	candles, err := candleClient.GetCandles(ctx, position.Symbol, position.BaseSymbol, t.interval, t.period+1)
+	if err != nil {
+		return 0, fmt.Errorf("TrailingATR::Stop : %w", err)
+	}
+	if len(candles) < 2 {
+		return 0, nil
+	}
+
+	return behind(position, position.BestPrice, averageTrueRange(candles)*t.multiplier), nil
+}
+
+func inProfit(position *types.Order) bool {
+	return position.BestPrice > 0 && position.Better(position.BestPrice, position.EntryPrice())
+}
+
+func averageTrueRange(candles []*commonTypes.Candle) float64 {
+	total := 0.0
+	for i := 1; i < len(candles); i++ {
+		previousClose := candles[i-1].Close
+		total += max(
+			candles[i].High-candles[i].Low,
+			math.Abs(candles[i].High-previousClose),
+			math.Abs(candles[i].Low-previousClose),
+		)
+	}
+
+	return total / float64(len(candles)-1)
+}
@@ -0,0 +1,812 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+const defaultLeverage float64 = 1
+
+type orderStates interface {
+	Create(ctx context.Context, order *types.Order) error
+	Transition(ctx context.Context, order *types.Order, status types.OrderStatus) error
+}
+
+type handlerRepository interface {
+	FindBySignal(ctx context.Context, signalUUID uuid.UUID) ([]*types.Order, error)
+}
+
+type riskManager interface {
+	CapLeverage(leverage float64) float64
+	Check(ctx context.Context, position *types.Order) error
+}
+
+type liquidationGuard interface {
+	Leverage(ctx context.Context, exchangeClient client.Client, position *types.Order) (float64, error)
+}
+
+type orderNormalizer interface {
+	Normalize(ctx context.Context, exchangeClient client.Client, symbol, baseSymbol string, price, quantity, referencePrice float64) (float64, float64, error)
+}
+
+type clientRegistry interface {
+	Get(exchange commonTypes.Exchange) (client.Client, error)
+}
+
+type HandlerOptions struct {
+	Clients         clientRegistry
+	OrderRepository handlerRepository
+	OrderStates     orderStates
+	// Normalizer is optional, orders are sent as planned without it
+	Normalizer orderNormalizer
+	// Risk is optional, every position is sent to exchange without it
+	Risk riskManager
+	// Liquidation is optional, leverage of futures position is not checked against its stop without it
+	Liquidation liquidationGuard
+	Logger      *logrus.Logger
+	// Channels holds execution options of every channel signals are traded from
+	Channels map[commonTypes.SignalChannel]*ChannelOptions
+}
+
+// Handler turns incoming signals into orders, stores them and sends them to exchange.
+type Handler struct {
+	clients         clientRegistry
+	orderRepository handlerRepository
+	orderStates     orderStates
+	normalizer      orderNormalizer
+	risk            riskManager
+	liquidation     liquidationGuard
+	log             *logrus.Logger
+	channels        map[commonTypes.SignalChannel]*ChannelOptions
+}
+
+func NewHandler(opt *HandlerOptions) *Handler {
+	return &Handler{
+		clients:         opt.Clients,
+		orderRepository: opt.OrderRepository,
+		orderStates:     opt.OrderStates,
+		normalizer:      opt.Normalizer,
+		risk:            opt.Risk,
+		liquidation:     opt.Liquidation,
+		log:             opt.Logger,
+		channels:        opt.Channels,
+	}
+}
+
+// ProcessSignal builds a position from signal and places its entry legs on exchange the signal is written for.
+// Position itself is never sent to exchange, it is the parent of leg orders.
+// Signal delivered again resumes placing of its position and never places a leg twice.
+func (h *Handler) ProcessSignal(ctx context.Context, signal *signalTypes.Signal) error {
+	channel, ok := h.channels[signal.Channel]
+	if !ok {
+		return fmt.Errorf("Handler::ProcessSignal : %w", types.ErrOrderChannelNotFound)
+	}
+
+	exchangeClient, err := h.clients.Get(signal.Exchange)
+	if err != nil {
+		return fmt.Errorf("Handler::ProcessSignal : %w", err)
+	}
+
+	position, legs, err := h.storedPosition(ctx, signal)
+	if err != nil {
+		return fmt.Errorf("Handler::ProcessSignal : %w", err)
+	}
+
+	log := h.log.WithField("SignalUUID", signal.UUID)
+	switch {
+	case position == nil:
+		position, legs, err = h.createPosition(ctx, exchangeClient, channel, signal)
+		if entrySkipped(err) {
+			log = log.WithField("OrderUUID", position.UUID)
+			log.WithError(err).Warn("Signal skipped, position can't be entered safely")
+			h.reject(ctx, position, log)
+
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Handler::ProcessSignal : %w", err)
+		}
+		log = log.WithField("OrderUUID", position.UUID)
+		log.Debug("Position saved to storage")
+	case position.Status == types.OrderStatusNew || position.Status == types.OrderStatusPending:
+		log = log.WithField("OrderUUID", position.UUID)
+		log.Warn("Signal is delivered again, resuming placing of its position")
+	default:
+		log.
+			WithFields(logrus.Fields{
+				"OrderUUID": position.UUID,
+				"Status":    position.Status,
+			}).
+			Info("Signal is already processed, skipping")
+
+		return nil
+	}
+
+	if position.Status == types.OrderStatusNew {
+		if h.risk != nil {
+			err := h.risk.Check(ctx, position)
+			if errors.Is(err, types.ErrOrderRiskRejected) {
+				// rejection is final, signal delivered again is skipped
+				h.reject(ctx, position, log)
+				for _, leg := range legs {
+					h.reject(ctx, leg, log)
+				}
+
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("Handler::ProcessSignal : %w", err)
+			}
+		}
+
+		if err := h.orderStates.Transition(ctx, position, types.OrderStatusPending); err != nil {
+			return fmt.Errorf("Handler::ProcessSignal : %w", err)
+		}
+	}
+
+	if futuresClient, ok := exchangeClient.(client.FuturesClient); ok {
+		if err := futuresClient.SetLeverage(ctx, position.Symbol, position.BaseSymbol, position.Leverage); err != nil {
+			// legs are never sent with leverage exchange refuses, signal delivered again is skipped
+			if !types.IsRetryable(err) {
+				h.reject(ctx, position, log)
+				for _, leg := range legs {
+					if leg.Status == types.OrderStatusNew {
+						h.reject(ctx, leg, log)
+					}
+				}
+			}
+
+			return fmt.Errorf("Handler::ProcessSignal : %w", err)
+		}
+	}
+
+	placed := 0
+	skip := false
+	retryable := false
+	for _, leg := range legs {
+		// legs saved but not sent are rejected, signal delivered again must not send them later
+		if skip && leg.Status == types.OrderStatusNew {
+			h.reject(ctx, leg, log)
+
+			continue
+		}
+
+		switch leg.Status {
+		case types.OrderStatusPlaced, types.OrderStatusPartiallyFilled, types.OrderStatusFilled:
+			// placed before signal was delivered again
+			placed++
+			continue
+		case types.OrderStatusNew, types.OrderStatusPending:
+		default:
+			continue
+		}
+
+		if err := h.placeOrder(ctx, exchangeClient, leg); err != nil {
+			category := client.CategoryOf(err)
+			log.
+				WithError(err).
+				WithFields(logrus.Fields{
+					"Leg":           leg.Leg,
+					"ErrorCategory": category,
+				}).
+				Error("Failed to place entry leg")
+
+			// the rest of legs is rejected by exchange the same way
+			if types.IsRetryable(err) {
+				retryable = true
+			} else {
+				skip = true
+			}
+
+			continue
+		}
+		placed++
+	}
+	if placed == 0 {
+		// failed leg is still pending, position waits for the next delivery of signal to place it
+		if retryable {
+			return fmt.Errorf("Handler::ProcessSignal : %w", types.ErrOrderLegsPending)
+		}
+
+		h.reject(ctx, position, log)
+
+		return fmt.Errorf("Handler::ProcessSignal : %w", types.ErrOrderLegsNotPlaced)
+	}
+
+	if err := h.orderStates.Transition(ctx, position, types.OrderStatusPlaced); err != nil {
+		return fmt.Errorf("Handler::ProcessSignal : %w", err)
+	}
+	log.
+		WithFields(logrus.Fields{
+			"Entry":    position.Entry,
+			"Quantity": position.Quantity,
+			"Leverage": position.Leverage,
+			"Legs":     placed,
+		}).
+		Info("Position sent to exchange")
+
+	return nil
+}
+
+// storedPosition returns position built from signal before together with its legs, nil when signal is new
+func (h *Handler) storedPosition(ctx context.Context, signal *signalTypes.Signal) (*types.Order, []*types.Order, error) {
+	orders, err := h.orderRepository.FindBySignal(ctx, signal.UUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var position *types.Order
+	for _, order := range orders {
+		if order.ParentUUID == uuid.Nil {
+			position = order
+			break
+		}
+	}
+	if position == nil {
+		return nil, nil, nil
+	}
+
+	legs := make([]*types.Order, 0, len(orders))
+	for _, order := range orders {
+		if order.ParentUUID == position.UUID {
+			legs = append(legs, order)
+		}
+	}
+	sort.Slice(legs, func(i, j int) bool { return legs[i].Leg < legs[j].Leg })
+
+	return position, legs, nil
+}
+
+// createPosition builds position from signal and saves it with all of its legs before any leg is sent,
+// so signal delivered again finds the whole plan. Position that live price or liquidation don't let
+// enter safely is saved without legs and returned together with the reason.
+func (h *Handler) createPosition(
+	ctx context.Context,
+	exchangeClient client.Client,
+	channel *ChannelOptions,
+	signal *signalTypes.Signal,
+) (*types.Order, []*types.Order, error) {
+	position, err := h.newOrder(ctx, exchangeClient, channel, signal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entryLegs, err := h.guardEntry(ctx, exchangeClient, channel, position, signal)
+	if err == nil {
+		err = h.guardLiquidation(ctx, exchangeClient, channel, position, signal)
+		// market leg enters the whole position
+		for _, entryLeg := range entryLegs {
+			entryLeg.Quantity = position.Quantity
+		}
+	}
+	if entrySkipped(err) {
+		if err := h.orderStates.Create(ctx, position); err != nil {
+			return nil, nil, err
+		}
+
+		return position, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if entryLegs == nil {
+		entryLegs, err = h.planEntry(channel, position, signal)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entryLegs, err = h.normalizeLegs(ctx, exchangeClient, position, entryLegs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := h.orderStates.Create(ctx, position); err != nil {
+		return nil, nil, err
+	}
+
+	legs := make([]*types.Order, 0, len(entryLegs))
+	for i, entryLeg := range entryLegs {
+		leg := newLegOrder(position, i, entryLeg)
+		if err := h.orderStates.Create(ctx, leg); err != nil {
+			return nil, nil, err
+		}
+		legs = append(legs, leg)
+	}
+
+	return position, legs, nil
+}
+
+// guardEntry checks live price against signal. Position is entered by a market leg it returns
+// when price is better than entry interval or worse but close enough to it, nil legs mean
+// position is entered as planned.
+func (h *Handler) guardEntry(
+	ctx context.Context,
+	exchangeClient client.Client,
+	channel *ChannelOptions,
+	position *types.Order,
+	signal *signalTypes.Signal,
+) ([]*types.EntryLeg, error) {
+	if channel.Slippage == nil {
+		return nil, nil
+	}
+
+	price, err := exchangeClient.GetPrice(ctx, position.Symbol, position.BaseSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if position.StopReached(price) {
+		return nil, fmt.Errorf("%w: price %g, stop %g", types.ErrOrderStopPassed, price, position.Stop)
+	}
+	if position.TargetReached(price) {
+		return nil, fmt.Errorf("%w: price %g, target %g", types.ErrOrderTargetPassed, price, position.NextTarget().Price)
+	}
+
+	interval := signal.EntryInterval
+	if price >= interval.Min && price <= interval.Max {
+		return nil, nil
+	}
+
+	// only price worse to enter at than interval is slippage, above it for long and below it for short
+	edge := interval.Max
+	if position.Position == commonTypes.PositionShort {
+		edge = interval.Min
+	}
+	slippage := 0.0
+	if position.Better(price, edge) {
+		slippage = math.Abs(price-edge) / edge * 100
+	}
+
+	switch channel.Slippage.Policy {
+	case EntryPolicyWait:
+		return nil, nil
+	case EntryPolicyMarket:
+		if slippage <= channel.Slippage.Tolerance {
+			// position is sized again for the price it is entered at
+			quantity, err := channel.Sizer.Size(ctx, exchangeClient, signal, price, position.Leverage)
+			if err != nil {
+				return nil, err
+			}
+			if quantity <= 0 {
+				return nil, types.ErrOrderQuantityInvalid
+			}
+			position.Entry = price
+			position.Quantity = quantity
+
+			return []*types.EntryLeg{{Type: commonTypes.OrderTypeMarket, Price: price, Quantity: position.Quantity}}, nil
+		}
+	}
+
+	// limit legs are filled at once when price is better than interval
+	if slippage == 0 {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: price %g, interval %g-%g", types.ErrOrderEntrySlipped, price, interval.Min, interval.Max)
+}
+
+// guardLiquidation lowers leverage of futures position until its stop comes before liquidation,
+// position is sized again for the lower leverage
+func (h *Handler) guardLiquidation(
+	ctx context.Context,
+	exchangeClient client.Client,
+	channel *ChannelOptions,
+	position *types.Order,
+	signal *signalTypes.Signal,
+) error {
+	if h.liquidation == nil {
+		return nil
+	}
+	if _, ok := exchangeClient.(client.FuturesClient); !ok {
+		return nil
+	}
+
+	leverage, err := h.liquidation.Leverage(ctx, exchangeClient, position)
+	if err != nil {
+		return err
+	}
+	if leverage >= position.Leverage {
+		return nil
+	}
+
+	quantity, err := channel.Sizer.Size(ctx, exchangeClient, signal, position.Entry, leverage)
+	if err != nil {
+		return err
+	}
+	if quantity <= 0 {
+		return types.ErrOrderQuantityInvalid
+	}
+
+	h.log.
+		WithFields(logrus.Fields{
+			"SignalUUID":  signal.UUID,
+			"Symbol":      position.Symbol,
+			"Stop":        position.Stop,
+			"Leverage":    position.Leverage,
+			"NewLeverage": leverage,
+		}).
+		Info("Leverage lowered so stop comes before liquidation")
+
+	position.Leverage = leverage
+	position.Quantity = quantity
+
+	return nil
+}
+
+// entrySkipped reports whether signal is skipped because live price or liquidation don't allow safe entry
+func entrySkipped(err error) bool {
+	return errors.Is(err, types.ErrOrderEntrySlipped) ||
+		errors.Is(err, types.ErrOrderTargetPassed) ||
+		errors.Is(err, types.ErrOrderStopPassed) ||
+		errors.Is(err, types.ErrOrderStopBeyondLiquidation)
+}
+
+// placeOrder sends leg order to exchange unless exchange already has order with its client order id.
+// Order failed to be sent is looked up by client order id as well before it is rejected.
+func (h *Handler) placeOrder(ctx context.Context, exchangeClient client.Client, order *types.Order) error {
+	log := h.log.WithFields(logrus.Fields{
+		"OrderUUID":     order.UUID,
+		"ParentUUID":    order.ParentUUID,
+		"ClientOrderID": order.ClientOrderID,
+	})
+
+	if order.Status == types.OrderStatusNew {
+		if err := h.orderStates.Transition(ctx, order, types.OrderStatusPending); err != nil {
+			return err
+		}
+	}
+
+	// leg stays pending when it is unknown whether it is sent, the next delivery of signal looks for it again
+	// and once position is placed reconciler does
+	exchangeOrder, err := findOnExchange(ctx, exchangeClient, order)
+	if err != nil {
+		return err
+	}
+
+	if exchangeOrder != nil {
+		order.ExchangeOrderID = commonTypes.OrderID(exchangeOrder.OrderID)
+		log.
+			WithField("ExchangeOrderID", order.ExchangeOrderID).
+			Warn("Order is already on exchange, it is not placed again")
+	} else {
+		switch c := exchangeClient.(type) {
+		case client.FuturesClient:
+			order.ExchangeOrderID, err = h.placeFuturesOrder(ctx, c, order)
+		case client.SpotClient:
+			order.ExchangeOrderID, err = h.placeSpotOrder(ctx, c, order)
+		default:
+			err = types.ErrOrderTypeNotSupported
+		}
+		if err != nil {
+			// request may reach exchange and fail after, retried request is then refused as duplicate
+			exchangeOrder, lookupErr := findOnExchange(ctx, exchangeClient, order)
+			if lookupErr != nil || exchangeOrder == nil {
+				if lookupErr == nil && !types.IsRetryable(err) {
+					h.reject(ctx, order, log)
+				}
+
+				return err
+			}
+
+			order.ExchangeOrderID = commonTypes.OrderID(exchangeOrder.OrderID)
+			log.
+				WithError(err).
+				WithField("ExchangeOrderID", order.ExchangeOrderID).
+				Warn("Order is on exchange although sending it failed")
+		}
+	}
+
+	if err := h.orderStates.Transition(ctx, order, types.OrderStatusPlaced); err != nil {
+		return err
+	}
+	log.
+		WithFields(logrus.Fields{
+			"Entry":           order.Entry,
+			"Quantity":        order.Quantity,
+			"ExchangeOrderID": order.ExchangeOrderID,
+		}).
+		Debug("Order sent to exchange")
+
+	return nil
+}
+
+// findOnExchange returns order exchange has with client order id of order, nil when there is none
+// or exchange can't look orders up by client order id
+func findOnExchange(ctx context.Context, exchangeClient client.Client, order *types.Order) (*commonTypes.Order, error) {
+	lookupClient, ok := exchangeClient.(client.OrderLookupClient)
+	if !ok || order.ClientOrderID == "" {
+		return nil, nil
+	}
+
+	exchangeOrder, err := lookupClient.GetOrderByClientID(ctx, order.Symbol, order.BaseSymbol, order.ClientOrderID)
+	if errors.Is(err, client.ErrOrderNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return exchangeOrder, nil
+}
+
+func (h *Handler) reject(ctx context.Context, order *types.Order, log *logrus.Entry) {
+	if err := h.orderStates.Transition(ctx, order, types.OrderStatusRejected); err != nil {
+		log.WithError(err).Error("Failed to mark order as rejected")
+	}
+}
+
+func (h *Handler) placeSpotOrder(ctx context.Context, spotClient client.SpotClient, order *types.Order) (commonTypes.OrderID, error) {
+	// spot can only buy what is going to grow
+	if order.Position != commonTypes.PositionLong {
+		return "", types.ErrOrderPositionNotSupported
+	}
+
+	return spotClient.CreateSpotOrder(ctx, &clientTypes.SpotOrder{
+		Exchange:      order.Exchange,
+		Type:          legType(order),
+		Position:      order.Position,
+		Symbol:        order.Symbol,
+		BaseSymbol:    order.BaseSymbol,
+		Entry:         order.Entry,
+		Quantity:      order.Quantity,
+		ClientOrderID: order.ClientOrderID,
+	})
+}
+
+func (h *Handler) placeFuturesOrder(ctx context.Context, futuresClient client.FuturesClient, order *types.Order) (commonTypes.OrderID, error) {
+	// targets are taken by manager in slices, only stop is kept on exchange
+	return futuresClient.CreateFuturesOrder(ctx, &clientTypes.FuturesOrder{
+		Exchange:      order.Exchange,
+		Type:          legType(order),
+		Position:      order.Position,
+		Symbol:        order.Symbol,
+		BaseSymbol:    order.BaseSymbol,
+		Entry:         order.Entry,
+		Quantity:      order.Quantity,
+		Leverage:      order.Leverage,
+		StopLoss:      order.Stop,
+		ClientOrderID: order.ClientOrderID,
+	})
+}
+
+// planEntry splits position into legs, position is entered by a single leg without planner
+func (h *Handler) planEntry(channel *ChannelOptions, position *types.Order, signal *signalTypes.Signal) ([]*types.EntryLeg, error) {
+	if channel.EntryPlanner == nil {
+		return []*types.EntryLeg{{Price: position.Entry, Quantity: position.Quantity}}, nil
+	}
+
+	return channel.EntryPlanner.Plan(position.Position, signal.EntryInterval, position.Quantity)
+}
+
+// normalizeLegs fits legs to symbol filters of exchange. When legs are too small for exchange
+// position is entered by a single leg, and when even that is too small types.MinimumError is returned.
+func (h *Handler) normalizeLegs(ctx context.Context, exchangeClient client.Client, position *types.Order, legs []*types.EntryLeg) ([]*types.EntryLeg, error) {
+	if h.normalizer == nil {
+		return legs, nil
+	}
+
+	normalized, err := h.normalizeEach(ctx, exchangeClient, position, legs)
+	if errors.Is(err, types.ErrOrderBelowMinimum) && len(legs) > 1 {
+		normalized, err = h.normalizeEach(ctx, exchangeClient, position, []*types.EntryLeg{{Price: position.Entry, Quantity: position.Quantity}})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// position holds what is really sent after rounding
+	position.Quantity = 0
+	for _, leg := range normalized {
+		position.Quantity += leg.Quantity
+	}
+
+	return normalized, nil
+}
+
+func (h *Handler) normalizeEach(ctx context.Context, exchangeClient client.Client, position *types.Order, legs []*types.EntryLeg) ([]*types.EntryLeg, error) {
+	normalized := make([]*types.EntryLeg, 0, len(legs))
+	for _, leg := range legs {
+		price, quantity, err := h.normalizer.Normalize(ctx, exchangeClient, position.Symbol, position.BaseSymbol, leg.Price, leg.Quantity, leg.Price)
+		if err != nil {
+			return nil, err
+		}
+
+		normalized = append(normalized, &types.EntryLeg{Type: leg.Type, Price: price, Quantity: quantity})
+	}
+
+	return normalized, nil
+}
+
+func (h *Handler) newOrder(
+	ctx context.Context,
+	exchangeClient client.Client,
+	channel *ChannelOptions,
+	signal *signalTypes.Signal,
+) (*types.Order, error) {
+	entry, err := pickEntry(signal)
+	if err != nil {
+		return nil, err
+	}
+
+	leverage, err := h.leverage(ctx, exchangeClient, channel, signal, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := allocateTargets(signal.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity, err := channel.Sizer.Size(ctx, exchangeClient, signal, entry, leverage)
+	if err != nil {
+		return nil, err
+	}
+	if quantity <= 0 {
+		return nil, types.ErrOrderQuantityInvalid
+	}
+
+	return &types.Order{
+		UUID:       uuid.New(),
+		SignalUUID: signal.UUID,
+		Channel:    signal.Channel,
+		CreatedAt:  time.Now(),
+		Exchange:   signal.Exchange,
+		Symbol:     signal.Symbol,
+		BaseSymbol: signal.BaseSymbol,
+		Position:   signal.Position,
+		Leverage:   leverage,
+		Entry:      entry,
+		Quantity:   quantity,
+		Targets:    targets,
+		Stop:       signal.Stop,
+		Status:     types.OrderStatusNew,
+	}, nil
+}
+
+func newLegOrder(position *types.Order, leg int, entryLeg *types.EntryLeg) *types.Order {
+	return &types.Order{
+		UUID:          uuid.New(),
+		ParentUUID:    position.UUID,
+		Leg:           leg,
+		ClientOrderID: types.NewClientOrderID(position.SignalUUID, leg),
+		SignalUUID:    position.SignalUUID,
+		Channel:       position.Channel,
+		CreatedAt:     time.Now(),
+		Exchange:      position.Exchange,
+		Symbol:        position.Symbol,
+		BaseSymbol:    position.BaseSymbol,
+		Position:      position.Position,
+		Type:          entryLeg.Type,
+		Leverage:      position.Leverage,
+		Entry:         entryLeg.Price,
+		Quantity:      entryLeg.Quantity,
+		Stop:          position.Stop,
+		Status:        types.OrderStatusNew,
+	}
+}
+
+// legType returns how leg is sent to exchange, legs without type are limit orders
+func legType(order *types.Order) commonTypes.OrderType {
+	if order.Type == "" {
+		return commonTypes.OrderTypeLimit
+	}
+
+	return order.Type
+}
+
+// allocateTargets gives every target a share of position. Targets without percent
+// split evenly what is left after the ones channel allocated explicitly.
+func allocateTargets(signalTargets []signalTypes.Target) ([]types.Target, error) {
+	allocated := 0.0
+	unallocated := 0
+	for _, target := range signalTargets {
+		if target.Percent < 0 {
+			return nil, types.ErrOrderTargetsInvalid
+		}
+		if target.Percent == 0 {
+			unallocated++
+		}
+		allocated += target.Percent
+	}
+	if allocated > 100 {
+		return nil, types.ErrOrderTargetsInvalid
+	}
+
+	targets := make([]types.Target, 0, len(signalTargets))
+	for _, target := range signalTargets {
+		percent := target.Percent
+		if percent == 0 {
+			percent = (100 - allocated) / float64(unallocated)
+		}
+
+		targets = append(targets, types.Target{
+			Price:   target.Price,
+			Percent: percent,
+		})
+	}
+
+	return targets, nil
+}
+
+// leverage picks leverage of position by channel policy, capped by risk limits and by exchange maximum for symbol
+func (h *Handler) leverage(
+	ctx context.Context,
+	exchangeClient client.Client,
+	channel *ChannelOptions,
+	signal *signalTypes.Signal,
+	entry float64,
+) (float64, error) {
+	// spot has no leverage
+	if _, ok := exchangeClient.(client.FuturesClient); !ok {
+		return defaultLeverage, nil
+	}
+
+	leverage := pickLeverage(signal)
+	if channel.Leverage != nil {
+		var err error
+		if leverage, err = channel.Leverage.Leverage(signal, entry); err != nil {
+			return 0, err
+		}
+	}
+	if h.risk != nil {
+		leverage = h.risk.CapLeverage(leverage)
+	}
+
+	info, err := exchangeClient.GetSymbolInfo(ctx, signal.Symbol, signal.BaseSymbol)
+	if err != nil {
+		return 0, fmt.Errorf("Handler::leverage : %w", err)
+	}
+	if info.MaxLeverage > 0 && leverage > info.MaxLeverage {
+		h.log.
+			WithFields(logrus.Fields{
+				"Exchange":    exchangeClient.Name(),
+				"Symbol":      signal.Symbol,
+				"Leverage":    leverage,
+				"MaxLeverage": info.MaxLeverage,
+			}).
+			Info("Leverage capped by exchange maximum")
+
+		leverage = info.MaxLeverage
+	}
+
+	return leverage, nil
+}
+
+// pickLeverage takes the lowest leverage suggested by signal
+func pickLeverage(signal *signalTypes.Signal) float64 {
+	if signal.LeverageInterval == nil || signal.LeverageInterval.Min < defaultLeverage {
+		return defaultLeverage
+	}
+
+	return signal.LeverageInterval.Min
+}
+
+// pickEntry takes the middle of signal entry interval, interval edges must be positive
+func pickEntry(signal *signalTypes.Signal) (float64, error) {
+	if signal.EntryInterval == nil {
+		return 0, types.ErrOrderEntryNotFound
+	}
+
+	if signal.EntryInterval.Min <= 0 || signal.EntryInterval.Max < signal.EntryInterval.Min {
+		return 0, fmt.Errorf("%w: interval %g-%g", types.ErrOrderEntryInvalid, signal.EntryInterval.Min, signal.EntryInterval.Max)
+	}
+
+	entry := (signal.EntryInterval.Min + signal.EntryInterval.Max) / 2
+
+	return entry, nil
+}
@@ -0,0 +1,605 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order"
+	"trade_bot/internal/order/leverage"
+	"trade_bot/internal/order/liquidation"
+	"trade_bot/internal/order/sizing"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// spotClient is a spot exchange keeping every order sent to it
+type spotClient struct {
+	client.Client
+	mu        sync.Mutex
+	price     float64
+	orders    []*clientTypes.SpotOrder
+	cancelled []commonTypes.OrderID
+	// createErr fails every order sent while it is set
+	createErr error
+	// lostReply keeps orders failed with createErr, as if exchange took them and only the reply was lost
+	lostReply bool
+	// free is spot balance of traded asset, zero is unlimited
+	free float64
+	// fills are orders as exchange reports them by exchange order id
+	fills map[commonTypes.OrderID]*commonTypes.Order
+}
+
+func (s *spotClient) Name() commonTypes.Exchange {
+	return commonTypes.ExchangeMexc
+}
+
+func (s *spotClient) GetPrice(_ context.Context, _, _ string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.price, nil
+}
+
+func (s *spotClient) GetAssets(_ context.Context, _ string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.free == 0 {
+		return math.MaxFloat64, nil
+	}
+
+	return s.free, nil
+}
+
+func (s *spotClient) CreateSpotOrder(_ context.Context, o *clientTypes.SpotOrder) (commonTypes.OrderID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.createErr != nil {
+		if s.lostReply {
+			s.orders = append(s.orders, o)
+		}
+
+		return "", s.createErr
+	}
+
+	s.orders = append(s.orders, o)
+	if o.ClientOrderID == "" {
+		return commonTypes.OrderID(uuid.NewString()), nil
+	}
+
+	return commonTypes.OrderID("exchange-" + o.ClientOrderID), nil
+}
+
+func (s *spotClient) GetOrderByClientID(_ context.Context, _, _, clientOrderID string) (*commonTypes.Order, error) {
+	if s.order(clientOrderID) == nil {
+		return nil, client.ErrOrderNotFound
+	}
+
+	return &commonTypes.Order{
+		OrderID:       "exchange-" + clientOrderID,
+		ClientOrderID: clientOrderID,
+	}, nil
+}
+
+func (s *spotClient) GetOrder(_ context.Context, _, _ string, orderID commonTypes.OrderID) (*commonTypes.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fill, ok := s.fills[orderID]
+	if !ok {
+		return nil, client.ErrOrderNotFound
+	}
+	o := *fill
+
+	return &o, nil
+}
+
+func (s *spotClient) CancelOrder(_ context.Context, _, _ string, orderID commonTypes.OrderID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancelled = append(s.cancelled, orderID)
+
+	return nil
+}
+
+func (s *spotClient) setCreateErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.createErr = err
+}
+
+func (s *spotClient) setPrice(price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.price = price
+}
+
+// sent returns every order sent to exchange from the first one
+func (s *spotClient) sent() []*clientTypes.SpotOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*clientTypes.SpotOrder(nil), s.orders...)
+}
+
+// order returns order sent with client order id, nil when there is none
+func (s *spotClient) order(clientOrderID string) *clientTypes.SpotOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, o := range s.orders {
+		if o.ClientOrderID == clientOrderID {
+			return o
+		}
+	}
+
+	return nil
+}
+
+func (s *spotClient) quantities() []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quantities := make([]float64, 0, len(s.orders))
+	for _, o := range s.orders {
+		quantities = append(quantities, o.Quantity)
+	}
+
+	return quantities
+}
+
+// futuresClient is a futures exchange limiting leverage of every symbol, its orders are kept as spot ones
+type futuresClient struct {
+	*spotClient
+	maxLeverage float64
+	leverage    float64
+	balance     float64
+	positions   []*clientTypes.OpenPosition
+	// leverageErr fails every leverage change while it is set
+	leverageErr error
+}
+
+func (f *futuresClient) setPositions(positions ...*clientTypes.OpenPosition) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.positions = positions
+}
+
+func (f *futuresClient) GetAssets(_ context.Context, _ string) (float64, error) {
+	return f.balance, nil
+}
+
+func (f *futuresClient) GetSymbolInfo(_ context.Context, _, _ string) (*clientTypes.SymbolInfo, error) {
+	return &clientTypes.SymbolInfo{MaxLeverage: f.maxLeverage}, nil
+}
+
+func (f *futuresClient) SetLeverage(_ context.Context, _, _ string, leverage float64) error {
+	if f.leverageErr != nil {
+		return f.leverageErr
+	}
+	f.leverage = leverage
+
+	return nil
+}
+
+func (f *futuresClient) CreateFuturesOrder(ctx context.Context, o *clientTypes.FuturesOrder) (commonTypes.OrderID, error) {
+	return f.CreateSpotOrder(ctx, &clientTypes.SpotOrder{
+		Type:          o.Type,
+		Position:      o.Position,
+		Symbol:        o.Symbol,
+		BaseSymbol:    o.BaseSymbol,
+		Quantity:      o.Quantity,
+		Entry:         o.Entry,
+		ClientOrderID: o.ClientOrderID,
+	})
+}
+
+func (f *futuresClient) GetPositions(_ context.Context, _, _ string) ([]*clientTypes.OpenPosition, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.positions, nil
+}
+
+// rejectingRisk rejects every position over open positions limit
+type rejectingRisk struct{}
+
+func (rejectingRisk) CapLeverage(leverage float64) float64 {
+	return leverage
+}
+
+func (rejectingRisk) Check(_ context.Context, _ *types.Order) error {
+	return &types.RiskError{Reason: types.RejectionReasonOpenPositions, Value: 2, Limit: 1}
+}
+
+func newTestHandler(repository *memoryRepository, exchangeClient client.Client, options ...func(*order.HandlerOptions)) *order.Handler {
+	opt := &order.HandlerOptions{
+		Clients:         client.NewRegistry(exchangeClient),
+		OrderRepository: repository,
+		OrderStates:     newTestStates(repository),
+		Logger:          logrus.New(),
+		Channels: map[commonTypes.SignalChannel]*order.ChannelOptions{
+			commonTypes.SignalChannelHardcoreVIP: {Sizer: sizing.NewFixedQuote(100)},
+		},
+	}
+	for _, option := range options {
+		option(opt)
+	}
+
+	return order.NewHandler(opt)
+}
+
+func newTestSignal() *signalTypes.Signal {
+	signal := signalTypes.NewSignal()
+	signal.Exchange = commonTypes.ExchangeMexc
+	signal.Channel = commonTypes.SignalChannelHardcoreVIP
+	signal.Symbol = "ETC"
+	signal.BaseSymbol = "USDT"
+	signal.Position = commonTypes.PositionLong
+	signal.EntryInterval = &commonTypes.Interval{Min: 19, Max: 21}
+	signal.Targets = []signalTypes.Target{{Price: 25}}
+	signal.Stop = 18
+
+	return signal
+}
+
+func TestHandlerProcessSignal(t *testing.T) {
+	repository := newMemoryRepository()
+	exchangeClient := &spotClient{}
+	handler := newTestHandler(repository, exchangeClient)
+	signal := newTestSignal()
+	signal.LeverageInterval = &commonTypes.Interval{Min: 25, Max: 50}
+
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+	orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+
+	var position, leg *types.Order
+	for _, o := range orders {
+		if o.ParentUUID == uuid.Nil {
+			position = o
+		} else {
+			leg = o
+		}
+	}
+	require.NotNil(t, position)
+	require.NotNil(t, leg)
+
+	// spot is traded without leverage whatever signal suggests
+	assert.Equal(t, 1.0, position.Leverage)
+	assert.Equal(t, 20.0, position.Entry, "middle of entry interval")
+	assert.Equal(t, 5.0, position.Quantity, "100 USDT of margin at entry")
+	assert.Equal(t, signal.Stop, position.Stop)
+	assert.Equal(t, types.OrderStatusPlaced, position.Status)
+	assert.Equal(t, types.OrderStatusPlaced, leg.Status)
+
+	sent := exchangeClient.order(leg.ClientOrderID)
+	require.NotNil(t, sent)
+	assert.Equal(t, commonTypes.OrderTypeLimit, sent.Type)
+	assert.Equal(t, 20.0, sent.Entry)
+	assert.Equal(t, 5.0, sent.Quantity)
+	assert.Equal(t, commonTypes.OrderID("exchange-"+leg.ClientOrderID), leg.ExchangeOrderID)
+}
+
+func TestHandlerProcessSignalErrors(t *testing.T) {
+	handler := newTestHandler(newMemoryRepository(), &spotClient{})
+
+	signal := newTestSignal()
+	signal.Channel = "unknown"
+	assert.ErrorIs(t, handler.ProcessSignal(context.Background(), signal), types.ErrOrderChannelNotFound)
+
+	signal = newTestSignal()
+	signal.Exchange = commonTypes.ExchangeBybit
+	assert.ErrorIs(t, handler.ProcessSignal(context.Background(), signal), client.ErrClientNotFound)
+
+	signal = newTestSignal()
+	signal.EntryInterval = nil
+	assert.ErrorIs(t, handler.ProcessSignal(context.Background(), signal), types.ErrOrderEntryNotFound)
+
+	signal = newTestSignal()
+	signal.EntryInterval = &commonTypes.Interval{Min: 0, Max: 21}
+	assert.ErrorIs(t, handler.ProcessSignal(context.Background(), signal), types.ErrOrderEntryInvalid)
+}
+
+func TestHandlerSkipsProcessedSignal(t *testing.T) {
+	repository := newMemoryRepository()
+	exchangeClient := &spotClient{}
+	handler := newTestHandler(repository, exchangeClient)
+	signal := newTestSignal()
+
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+	orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+	require.NoError(t, err)
+	assert.Len(t, orders, 2, "position and its single leg are created once")
+	assert.Len(t, exchangeClient.sent(), 1)
+	assert.NotNil(t, exchangeClient.order(types.NewClientOrderID(signal.UUID, 0)))
+}
+
+func TestHandlerFindsLegSentBeforeCrash(t *testing.T) {
+	repository := newMemoryRepository()
+	exchangeClient := &spotClient{}
+	handler := newTestHandler(repository, exchangeClient)
+	signal := newTestSignal()
+
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+	// leg reached exchange, but process died before the answer was saved
+	orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+	require.NoError(t, err)
+	var leg *types.Order
+	for _, o := range orders {
+		if o.ParentUUID == uuid.Nil {
+			repository.setStatus(o.UUID, types.OrderStatusPending)
+		} else {
+			leg = o
+			repository.setStatus(o.UUID, types.OrderStatusPending)
+		}
+	}
+	require.NotNil(t, leg)
+
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+	stored := repository.get(leg.UUID)
+	assert.Equal(t, types.OrderStatusPlaced, stored.Status)
+	assert.Equal(t, commonTypes.OrderID("exchange-"+leg.ClientOrderID), stored.ExchangeOrderID)
+	assert.Len(t, exchangeClient.sent(), 1)
+	assert.Equal(t, types.OrderStatusPlaced, repository.get(leg.ParentUUID).Status)
+}
+
+func TestHandlerAdoptsLegFailedAfterReachingExchange(t *testing.T) {
+	repository := newMemoryRepository()
+	// retry after timeout is refused as duplicate of the order sent before
+	exchangeClient := &spotClient{
+		createErr: errors.New("duplicate client order id"),
+		lostReply: true,
+	}
+	handler := newTestHandler(repository, exchangeClient)
+	signal := newTestSignal()
+
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+	orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+	require.NoError(t, err)
+	for _, o := range orders {
+		assert.Equal(t, types.OrderStatusPlaced, o.Status)
+		if o.ParentUUID != uuid.Nil {
+			assert.Equal(t, commonTypes.OrderID("exchange-"+o.ClientOrderID), o.ExchangeOrderID)
+		}
+	}
+}
+
+func TestHandlerRetriesLegsFailedTransiently(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		position types.OrderStatus
+		leg      types.OrderStatus
+	}{
+		{"network failure", errors.New("connection reset"), types.OrderStatusPending, types.OrderStatusPending},
+		{"rate limit", &client.MexcError{StatusCode: http.StatusTooManyRequests}, types.OrderStatusPending, types.OrderStatusPending},
+		{"auth failure", &client.MexcError{StatusCode: http.StatusUnauthorized}, types.OrderStatusRejected, types.OrderStatusRejected},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newMemoryRepository()
+			exchangeClient := &spotClient{createErr: test.err}
+			handler := newTestHandler(repository, exchangeClient)
+			signal := newTestSignal()
+
+			err := handler.ProcessSignal(context.Background(), signal)
+			require.Error(t, err)
+
+			orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+			require.NoError(t, err)
+			require.Len(t, orders, 2)
+			for _, o := range orders {
+				if o.ParentUUID == uuid.Nil {
+					assert.Equal(t, test.position, o.Status)
+				} else {
+					assert.Equal(t, test.leg, o.Status)
+				}
+			}
+
+			if test.position != types.OrderStatusPending {
+				return
+			}
+
+			// the next delivery of signal places the leg once exchange takes orders again
+			exchangeClient.setCreateErr(nil)
+			require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+			for _, o := range orders {
+				assert.Equal(t, types.OrderStatusPlaced, repository.get(o.UUID).Status)
+			}
+			assert.Len(t, exchangeClient.sent(), 1)
+		})
+	}
+}
+
+func TestHandlerRejectsPositionOverRiskLimits(t *testing.T) {
+	repository := newMemoryRepository()
+	exchangeClient := &spotClient{}
+	handler := newTestHandler(repository, exchangeClient, func(opt *order.HandlerOptions) {
+		opt.Risk = rejectingRisk{}
+	})
+	signal := newTestSignal()
+
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+	orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+	require.NoError(t, err)
+	require.Len(t, orders, 2, "rejected signal is not built again")
+	for _, o := range orders {
+		assert.Equal(t, types.OrderStatusRejected, o.Status)
+	}
+	assert.Empty(t, exchangeClient.sent())
+}
+
+func TestHandlerRejectsLegsWhenLeverageRefused(t *testing.T) {
+	repository := newMemoryRepository()
+	exchangeClient := &futuresClient{
+		spotClient:  &spotClient{},
+		balance:     1000,
+		leverageErr: &client.MexcError{StatusCode: http.StatusUnauthorized},
+	}
+	handler := newTestHandler(repository, exchangeClient)
+	signal := newTestSignal()
+
+	require.Error(t, handler.ProcessSignal(context.Background(), signal))
+	require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+	orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	for _, o := range orders {
+		assert.Equal(t, types.OrderStatusRejected, o.Status)
+	}
+	assert.Empty(t, exchangeClient.sent())
+}
+
+func TestHandlerGuardsEntryByLivePrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		slippage *order.SlippageOptions
+		price    float64
+		// orderType is type of entry order sent, empty when signal is skipped
+		orderType commonTypes.OrderType
+		entry     float64
+	}{
+		{"inside interval", &order.SlippageOptions{Policy: order.EntryPolicySkip}, 20.5, commonTypes.OrderTypeLimit, 20},
+		{"skipped above interval", &order.SlippageOptions{Policy: order.EntryPolicySkip}, 21.1, "", 0},
+		{"waits above interval", &order.SlippageOptions{Policy: order.EntryPolicyWait}, 21.1, commonTypes.OrderTypeLimit, 20},
+		{"market within tolerance", &order.SlippageOptions{Policy: order.EntryPolicyMarket, Tolerance: 1}, 21.1, commonTypes.OrderTypeMarket, 21.1},
+		{"market below interval", &order.SlippageOptions{Policy: order.EntryPolicyMarket, Tolerance: 1}, 18.5, commonTypes.OrderTypeMarket, 18.5},
+		{"market over tolerance", &order.SlippageOptions{Policy: order.EntryPolicyMarket, Tolerance: 1}, 22, "", 0},
+		{"below interval is not slipped", &order.SlippageOptions{Policy: order.EntryPolicySkip}, 18.5, commonTypes.OrderTypeLimit, 20},
+		{"target passed", &order.SlippageOptions{Policy: order.EntryPolicyWait}, 25.5, "", 0},
+		{"stop passed", &order.SlippageOptions{Policy: order.EntryPolicyWait}, 17.5, "", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newMemoryRepository()
+			exchangeClient := &spotClient{price: test.price}
+			handler := newTestHandler(repository, exchangeClient, func(opt *order.HandlerOptions) {
+				opt.Channels[commonTypes.SignalChannelHardcoreVIP].Slippage = test.slippage
+			})
+			signal := newTestSignal()
+
+			require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+			orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+			require.NoError(t, err)
+
+			if test.orderType == "" {
+				assert.Empty(t, exchangeClient.sent())
+				require.Len(t, orders, 1, "skipped signal has no legs")
+				assert.Equal(t, types.OrderStatusRejected, orders[0].Status)
+
+				return
+			}
+
+			sent := exchangeClient.order(types.NewClientOrderID(signal.UUID, 0))
+			require.NotNil(t, sent)
+			assert.Equal(t, test.orderType, sent.Type)
+			assert.Equal(t, test.entry, sent.Entry)
+			assert.InDelta(t, 100/test.entry, sent.Quantity, 1e-9, "position is sized for the price it is entered at")
+		})
+	}
+}
+
+func TestHandlerPicksLeverageByChannelPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      order.LeveragePolicy
+		maxLeverage float64
+		leverage    float64
+	}{
+		{"lowest of signal without policy", nil, 0, 25},
+		{"channel policy", leverage.NewMax(), 0, 50},
+		{"capped by exchange", leverage.NewMax(), 30, 30},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newMemoryRepository()
+			exchangeClient := &futuresClient{spotClient: &spotClient{}, maxLeverage: test.maxLeverage}
+			handler := newTestHandler(repository, exchangeClient, func(opt *order.HandlerOptions) {
+				opt.Channels[commonTypes.SignalChannelHardcoreVIP].Leverage = test.policy
+			})
+			signal := newTestSignal()
+			signal.LeverageInterval = &commonTypes.Interval{Min: 25, Max: 50}
+
+			require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+			assert.Equal(t, test.leverage, exchangeClient.leverage)
+			assert.Len(t, exchangeClient.sent(), 1)
+		})
+	}
+}
+
+func TestHandlerKeepsStopBeforeLiquidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		marginMode commonTypes.MarginMode
+		leverage   float64
+		quantity   float64
+	}{
+		// 50x is liquidated at 19.7, 9x is the highest leverage liquidated below stop 18
+		{"leverage lowered", commonTypes.MarginModeIsolated, 9, 45},
+		// balance of 20 doesn't cover maintenance margin of 250 ETC, whatever leverage is
+		{"rejected in cross margin", commonTypes.MarginModeCross, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newMemoryRepository()
+			exchangeClient := &futuresClient{spotClient: &spotClient{}, balance: 20}
+			handler := newTestHandler(repository, exchangeClient, func(opt *order.HandlerOptions) {
+				opt.Liquidation = liquidation.NewGuard(&liquidation.GuardOptions{MarginMode: test.marginMode, MaintenanceRate: 0.005})
+				opt.Channels[commonTypes.SignalChannelHardcoreVIP].Leverage = leverage.NewMax()
+			})
+			signal := newTestSignal()
+			signal.LeverageInterval = &commonTypes.Interval{Min: 25, Max: 50}
+
+			require.NoError(t, handler.ProcessSignal(context.Background(), signal))
+
+			if test.leverage == 0 {
+				assert.Empty(t, exchangeClient.sent())
+				orders, err := repository.FindBySignal(context.Background(), signal.UUID)
+				require.NoError(t, err)
+				require.Len(t, orders, 1)
+				assert.Equal(t, types.OrderStatusRejected, orders[0].Status)
+
+				return
+			}
+
+			assert.Equal(t, test.leverage, exchangeClient.leverage)
+			sent := exchangeClient.order(types.NewClientOrderID(signal.UUID, 0))
+			require.NotNil(t, sent)
+			assert.InDelta(t, test.quantity, sent.Quantity, 1e-9)
+		})
+	}
+}
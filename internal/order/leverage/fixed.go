@@ -0,0 +1,20 @@
+package leverage
+
+import (
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+// Fixed uses the same leverage for every signal whatever it suggests
+type Fixed struct {
+	leverage float64
+}
+
+func NewFixed(leverage float64) *Fixed {
+	return &Fixed{
+		leverage: leverage,
+	}
+}
+
+func (f *Fixed) Leverage(_ *signalTypes.Signal, _ float64) (float64, error) {
+	return whole(f.leverage), nil
+}
@@ -0,0 +1,46 @@
+package leverage
+
+import (
+	"math"
+
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+// defaultLeverage is used for signal without leverage
+const defaultLeverage float64 = 1
+
+// Interval picks leverage inside leverage interval of signal, point 0 is its min and 1 is its max.
+// Signal without leverage interval is traded without leverage.
+type Interval struct {
+	point float64
+}
+
+// NewMin takes the lowest leverage suggested by signal
+func NewMin() *Interval {
+	return &Interval{point: 0}
+}
+
+// NewMax takes the highest leverage suggested by signal
+func NewMax() *Interval {
+	return &Interval{point: 1}
+}
+
+// NewMidpoint takes the middle of leverage interval of signal
+func NewMidpoint() *Interval {
+	return &Interval{point: 0.5}
+}
+
+func (i *Interval) Leverage(signal *signalTypes.Signal, _ float64) (float64, error) {
+	if signal.LeverageInterval == nil {
+		return defaultLeverage, nil
+	}
+
+	interval := signal.LeverageInterval
+
+	return whole(interval.Min + (interval.Max-interval.Min)*i.point), nil
+}
+
+// whole rounds leverage down to integer accepted by every exchange, but not below default
+func whole(leverage float64) float64 {
+	return math.Max(math.Floor(leverage), defaultLeverage)
+}
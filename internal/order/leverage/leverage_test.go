@@ -0,0 +1,48 @@
+package leverage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/order"
+	"trade_bot/internal/order/leverage"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+func TestLeverage(t *testing.T) {
+	signal := &signalTypes.Signal{
+		LeverageInterval: &commonTypes.Interval{Min: 25, Max: 50},
+		Stop:             19,
+	}
+
+	tests := []struct {
+		name     string
+		policy   order.LeveragePolicy
+		signal   *signalTypes.Signal
+		leverage float64
+	}{
+		{"min", leverage.NewMin(), signal, 25},
+		{"max", leverage.NewMax(), signal, 50},
+		{"midpoint is rounded down", leverage.NewMidpoint(), signal, 37},
+		{"signal without leverage", leverage.NewMax(), &signalTypes.Signal{}, 1},
+		{"fixed ignores signal", leverage.NewFixed(10), signal, 10},
+		// stop is 5% away, losing 50% of margin at stop allows 10x
+		{"stop distance", leverage.NewStopDistance(50), signal, 10},
+		{"stop distance is at least 1", leverage.NewStopDistance(2), signal, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := test.policy.Leverage(test.signal, 20)
+			require.NoError(t, err)
+			assert.Equal(t, test.leverage, value)
+		})
+	}
+
+	_, err := leverage.NewStopDistance(50).Leverage(&signalTypes.Signal{}, 20)
+	assert.ErrorIs(t, err, types.ErrOrderStopInvalid)
+}
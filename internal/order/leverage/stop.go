@@ -0,0 +1,33 @@
+package leverage
+
+import (
+	"math"
+
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+// StopDistance picks the highest leverage at which reaching signal stop loses no more than percent of margin
+type StopDistance struct {
+	percent float64
+}
+
+func NewStopDistance(percent float64) *StopDistance {
+	return &StopDistance{
+		percent: percent,
+	}
+}
+
+func (s *StopDistance) Leverage(signal *signalTypes.Signal, entry float64) (float64, error) {
+	if entry <= 0 {
+		return 0, types.ErrOrderEntryNotFound
+	}
+
+	stopDistance := math.Abs(entry - signal.Stop)
+	if signal.Stop <= 0 || stopDistance == 0 {
+		return 0, types.ErrOrderStopInvalid
+	}
+
+	// margin loses leverage times the price move
+	return whole(s.percent / (stopDistance / entry * 100)), nil
+}
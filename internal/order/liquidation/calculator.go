@@ -0,0 +1,59 @@
+package liquidation
+
+import (
+	"math"
+
+	clientTypes "trade_bot/internal/client/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// Position holds what liquidation price of leveraged position depends on
+type Position struct {
+	Side       commonTypes.Position
+	MarginMode commonTypes.MarginMode
+	Entry      float64
+	Quantity   float64
+	Leverage   float64
+	// Balance is collateral of account backing cross margin position, isolated position is backed by its own margin
+	Balance float64
+}
+
+// Price returns price position is liquidated at, when its margin less maintenance margin is lost.
+// Maintenance margin is taken from tier of position value, the highest tier is used above all tiers.
+// Zero is returned for long position price can't fall low enough to liquidate.
+func Price(position *Position, tiers []*clientTypes.MarginTier) float64 {
+	if position.Entry <= 0 || position.Quantity <= 0 {
+		return 0
+	}
+
+	value := position.Entry * position.Quantity
+	tier := tierOf(value, tiers)
+	maintenance := value*tier.MaintenanceRate - tier.MaintenanceDeduction
+
+	margin := position.Balance
+	if position.MarginMode != commonTypes.MarginModeCross {
+		margin = value / position.Leverage
+	}
+
+	distance := (margin - maintenance) / position.Quantity
+	if position.Side == commonTypes.PositionShort {
+		return position.Entry + distance
+	}
+
+	return math.Max(position.Entry-distance, 0)
+}
+
+// tierOf returns the lowest tier holding value
+func tierOf(value float64, tiers []*clientTypes.MarginTier) *clientTypes.MarginTier {
+	if len(tiers) == 0 {
+		return &clientTypes.MarginTier{}
+	}
+
+	for _, tier := range tiers {
+		if value <= tier.MaxValue {
+			return tier
+		}
+	}
+
+	return tiers[len(tiers)-1]
+}
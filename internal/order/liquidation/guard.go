@@ -0,0 +1,98 @@
+package liquidation
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type GuardOptions struct {
+	MarginMode commonTypes.MarginMode
+	// MaintenanceRate is used for exchange not telling margin tiers of symbol
+	MaintenanceRate float64
+}
+
+// Guard keeps liquidation price of leveraged position behind its stop, so stop fires first
+type Guard struct {
+	marginMode      commonTypes.MarginMode
+	maintenanceRate float64
+}
+
+func NewGuard(opt *GuardOptions) *Guard {
+	marginMode := opt.MarginMode
+	if marginMode == "" {
+		marginMode = commonTypes.MarginModeIsolated
+	}
+
+	return &Guard{
+		marginMode:      marginMode,
+		maintenanceRate: opt.MaintenanceRate,
+	}
+}
+
+// Leverage returns the highest leverage not above leverage of position at which position is liquidated
+// only past its stop. Error matching types.ErrOrderStopBeyondLiquidation is returned when there is none.
+func (g *Guard) Leverage(ctx context.Context, exchangeClient client.Client, position *types.Order) (float64, error) {
+	if position.Stop <= 0 || position.Leverage <= 0 {
+		return position.Leverage, nil
+	}
+
+	tiers, err := g.tiers(ctx, exchangeClient, position)
+	if err != nil {
+		return 0, fmt.Errorf("Guard::Leverage : %w", err)
+	}
+
+	candidate := &Position{
+		Side:       position.Position,
+		MarginMode: g.marginMode,
+		Entry:      position.Entry,
+		Quantity:   position.Quantity,
+		Leverage:   position.Leverage,
+	}
+	if g.marginMode == commonTypes.MarginModeCross {
+		if candidate.Balance, err = exchangeClient.GetAssets(ctx, position.BaseSymbol); err != nil {
+			return 0, fmt.Errorf("Guard::Leverage : %w", err)
+		}
+	}
+
+	for {
+		price := Price(candidate, tiers)
+		if stopFirst(position, price) {
+			return candidate.Leverage, nil
+		}
+
+		// leverage doesn't move liquidation of cross margin position, nor is there lower leverage than 1
+		if g.marginMode == commonTypes.MarginModeCross || candidate.Leverage <= 1 {
+			return 0, fmt.Errorf(
+				"%w: stop %g, liquidation %g at leverage %g",
+				types.ErrOrderStopBeyondLiquidation, position.Stop, price, candidate.Leverage,
+			)
+		}
+
+		candidate.Leverage = math.Max(math.Ceil(candidate.Leverage)-1, 1)
+	}
+}
+
+// tiers returns margin tiers of symbol, or a single tier of default rate when exchange doesn't tell them
+func (g *Guard) tiers(ctx context.Context, exchangeClient client.Client, position *types.Order) ([]*clientTypes.MarginTier, error) {
+	tierClient, ok := exchangeClient.(client.MarginTierClient)
+	if !ok {
+		return []*clientTypes.MarginTier{{MaintenanceRate: g.maintenanceRate}}, nil
+	}
+
+	return tierClient.GetMarginTiers(ctx, position.Symbol, position.BaseSymbol)
+}
+
+// stopFirst reports whether price reaches stop of position before liquidation price
+func stopFirst(position *types.Order, liquidation float64) bool {
+	if position.Position == commonTypes.PositionShort {
+		return position.Stop < liquidation
+	}
+
+	return position.Stop > liquidation
+}
@@ -0,0 +1,135 @@
+package liquidation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order/liquidation"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type tierClient struct {
+	client.FuturesClient
+	balance float64
+	tiers   []*clientTypes.MarginTier
+}
+
+func (t *tierClient) GetAssets(_ context.Context, _ string) (float64, error) {
+	return t.balance, nil
+}
+
+func (t *tierClient) GetMarginTiers(_ context.Context, _, _ string) ([]*clientTypes.MarginTier, error) {
+	return t.tiers, nil
+}
+
+func TestPrice(t *testing.T) {
+	flat := []*clientTypes.MarginTier{{MaxValue: 1000, MaintenanceRate: 0.005}}
+	tiered := []*clientTypes.MarginTier{
+		{MaxValue: 100, MaintenanceRate: 0.01},
+		{MaxValue: 1000, MaintenanceRate: 0.02, MaintenanceDeduction: 1},
+	}
+
+	tests := []struct {
+		name     string
+		position *liquidation.Position
+		tiers    []*clientTypes.MarginTier
+		price    float64
+	}{
+		{
+			name:     "isolated long",
+			position: &liquidation.Position{Side: commonTypes.PositionLong, Entry: 20, Quantity: 10, Leverage: 10},
+			tiers:    flat,
+			price:    18.1,
+		},
+		{
+			name:     "isolated short",
+			position: &liquidation.Position{Side: commonTypes.PositionShort, Entry: 20, Quantity: 10, Leverage: 10},
+			tiers:    flat,
+			price:    21.9,
+		},
+		{
+			// value 200 falls into the second tier, maintenance margin is 200 * 2% - 1
+			name:     "tier of position value",
+			position: &liquidation.Position{Side: commonTypes.PositionLong, Entry: 20, Quantity: 10, Leverage: 10},
+			tiers:    tiered,
+			price:    18.3,
+		},
+		{
+			name: "cross long",
+			position: &liquidation.Position{
+				Side: commonTypes.PositionLong, MarginMode: commonTypes.MarginModeCross,
+				Entry: 20, Quantity: 10, Leverage: 10, Balance: 100,
+			},
+			tiers: flat,
+			price: 10.1,
+		},
+		{
+			name: "cross long never liquidated",
+			position: &liquidation.Position{
+				Side: commonTypes.PositionLong, MarginMode: commonTypes.MarginModeCross,
+				Entry: 20, Quantity: 10, Leverage: 10, Balance: 1000,
+			},
+			tiers: flat,
+			price: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.InDelta(t, test.price, liquidation.Price(test.position, test.tiers), 1e-9)
+		})
+	}
+}
+
+func TestGuardLeverage(t *testing.T) {
+	flat := []*clientTypes.MarginTier{{MaxValue: 1000, MaintenanceRate: 0.005}}
+	position := func(side commonTypes.Position, stop, leverage float64) *types.Order {
+		return &types.Order{
+			Symbol:     "ETC",
+			BaseSymbol: "USDT",
+			Position:   side,
+			Entry:      20,
+			Quantity:   10,
+			Leverage:   leverage,
+			Stop:       stop,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		marginMode commonTypes.MarginMode
+		balance    float64
+		position   *types.Order
+		leverage   float64
+	}{
+		{"stop before liquidation", commonTypes.MarginModeIsolated, 0, position(commonTypes.PositionLong, 19, 10), 10},
+		// liquidation is at 18.1 for 10x, 17.88 for 9x
+		{"leverage lowered", commonTypes.MarginModeIsolated, 0, position(commonTypes.PositionLong, 18, 10), 9},
+		{"short leverage lowered", commonTypes.MarginModeIsolated, 0, position(commonTypes.PositionShort, 22, 10), 9},
+		{"short rejected", commonTypes.MarginModeIsolated, 0, position(commonTypes.PositionShort, 45, 10), 0},
+		{"cross stop before liquidation", commonTypes.MarginModeCross, 100, position(commonTypes.PositionLong, 18, 10), 10},
+		{"cross rejected", commonTypes.MarginModeCross, 10, position(commonTypes.PositionLong, 18, 10), 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			guard := liquidation.NewGuard(&liquidation.GuardOptions{MarginMode: test.marginMode})
+
+			leverage, err := guard.Leverage(context.Background(), &tierClient{balance: test.balance, tiers: flat}, test.position)
+			if test.leverage == 0 {
+				assert.ErrorIs(t, err, types.ErrOrderStopBeyondLiquidation)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.leverage, leverage)
+		})
+	}
+}
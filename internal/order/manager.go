@@ -0,0 +1,568 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type managerRepository interface {
+	FindPositions(ctx context.Context, statuses ...types.OrderStatus) ([]*types.Order, error)
+	FindLegs(ctx context.Context, parentUUID uuid.UUID) ([]*types.Order, error)
+	Get(ctx context.Context, orderUUID uuid.UUID) (*types.Order, error)
+	Update(ctx context.Context, order *types.Order) error
+	UpdateFill(ctx context.Context, order *types.Order) error
+	TakeTarget(ctx context.Context, order *types.Order, quantity float64) error
+	ReleaseTarget(ctx context.Context, order *types.Order, quantity float64) error
+}
+
+type ManagerOptions struct {
+	Clients         clientRegistry
+	OrderRepository managerRepository
+	OrderStates     orderStates
+	// Normalizer is optional, exit quantities are sent as computed without it
+	Normalizer orderNormalizer
+	// Channels holds stop rules of positions opened from channel signals
+	Channels map[commonTypes.SignalChannel]*ChannelOptions
+	// Interval is how often prices of open positions are checked
+	Interval time.Duration
+	Logger   *logrus.Logger
+}
+
+// Manager watches prices of open positions and manages their orders on exchange. It takes targets
+// of every position and sells spot positions on stop, as spot has no bracket orders on exchange.
+// Futures positions closed on exchange by their stop or liquidation are closed in storage as well.
+type Manager struct {
+	clients         clientRegistry
+	orderRepository managerRepository
+	orderStates     orderStates
+	normalizer      orderNormalizer
+	channels        map[commonTypes.SignalChannel]*ChannelOptions
+	interval        time.Duration
+	log             *logrus.Logger
+}
+
+func NewManager(opt *ManagerOptions) *Manager {
+	return &Manager{
+		clients:         opt.Clients,
+		orderRepository: opt.OrderRepository,
+		orderStates:     opt.OrderStates,
+		normalizer:      opt.Normalizer,
+		channels:        opt.Channels,
+		interval:        opt.Interval,
+		log:             opt.Logger,
+	}
+}
+
+func (m *Manager) Start(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.log.Info("Manager context cancelled, stopping position management")
+			return nil
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *Manager) Stop(ctx context.Context) error {
+	m.log.Info("Stopping position manager")
+
+	return nil
+}
+
+func (m *Manager) check(ctx context.Context) {
+	positions, err := m.orderRepository.FindPositions(
+		ctx,
+		types.OrderStatusPlaced,
+		types.OrderStatusPartiallyFilled,
+		types.OrderStatusFilled,
+		types.OrderStatusClosing,
+	)
+	if err != nil {
+		m.log.WithError(err).Error("Failed to find open positions")
+
+		return
+	}
+
+	for _, position := range positions {
+		log := m.log.WithFields(logrus.Fields{
+			"OrderUUID": position.UUID,
+			"Exchange":  position.Exchange,
+			"Symbol":    position.Symbol,
+		})
+
+		if err := m.checkPosition(ctx, position, log); err != nil {
+			log.WithError(err).Error("Failed to check position")
+		}
+	}
+}
+
+func (m *Manager) checkPosition(ctx context.Context, position *types.Order, log *logrus.Entry) error {
+	exchangeClient, err := m.clients.Get(position.Exchange)
+	if err != nil {
+		return fmt.Errorf("Manager::checkPosition : %w", err)
+	}
+
+	price, err := exchangeClient.GetPrice(ctx, position.Symbol, position.BaseSymbol)
+	if err != nil {
+		return fmt.Errorf("Manager::checkPosition : %w", err)
+	}
+
+	// futures position may be closed on exchange by the stop kept there or by liquidation
+	if futuresClient, ok := exchangeClient.(client.FuturesClient); ok && position.FilledQuantity > 0 {
+		open, err := m.openOnExchange(ctx, futuresClient, position)
+		if err != nil {
+			return fmt.Errorf("Manager::checkPosition : %w", err)
+		}
+		if !open {
+			if err := m.settlePosition(ctx, exchangeClient, position, price, log); err != nil {
+				return fmt.Errorf("Manager::checkPosition : %w", err)
+			}
+
+			return nil
+		}
+	}
+
+	// close was claimed earlier but didn't finish
+	if position.Status == types.OrderStatusClosing {
+		if err := m.closePosition(ctx, exchangeClient, position, price, log); err != nil {
+			return fmt.Errorf("Manager::checkPosition : %w", err)
+		}
+
+		return nil
+	}
+
+	if err := m.moveStop(ctx, exchangeClient, position, price, log); err != nil {
+		return fmt.Errorf("Manager::checkPosition : %w", err)
+	}
+
+	stopReached := position.StopReached(price)
+	targetReached := position.TargetReached(price)
+
+	// entry is not needed anymore once price left the trade or it waited too long
+	switch {
+	case stopReached:
+		err = m.cancelLegs(ctx, exchangeClient, position, types.OrderStatusCancelled, log)
+	case targetReached:
+		err = m.cancelLegs(ctx, exchangeClient, position, types.OrderStatusMissed, log)
+	case m.entryExpired(position):
+		err = m.cancelLegs(ctx, exchangeClient, position, types.OrderStatusExpired, log)
+	}
+	if err != nil {
+		return fmt.Errorf("Manager::checkPosition : %w", err)
+	}
+
+	// nothing is entered, there are no targets to take. Reconciler marks position missed once its legs are done.
+	if targetReached && !stopReached && position.Status == types.OrderStatusPlaced && position.FilledQuantity == 0 {
+		return nil
+	}
+
+	if stopReached {
+		// futures keep signal stop on exchange and are settled once exchange closes them,
+		// moved stops and spot without bracket are closed by manager
+		if _, ok := exchangeClient.(client.FuturesClient); ok && !position.StopMoved {
+			return nil
+		}
+
+		log.WithField("Price", price).Info("Position stop reached")
+		if err := m.closePosition(ctx, exchangeClient, position, price, log); err != nil {
+			return fmt.Errorf("Manager::checkPosition : %w", err)
+		}
+
+		return nil
+	}
+
+	if err := m.takeTargets(ctx, exchangeClient, position, price, log); err != nil {
+		return fmt.Errorf("Manager::checkPosition : %w", err)
+	}
+
+	return nil
+}
+
+// moveStop follows the best price of position and lets stop rules of its channel tighten stop.
+// Moved stop is set on exchange too when exchange allows it, manager closes position on it either way.
+func (m *Manager) moveStop(ctx context.Context, exchangeClient client.Client, position *types.Order, price float64, log *logrus.Entry) error {
+	// stop is measured from fills, best price of position nothing is bought for yet doesn't count
+	if position.FilledQuantity == 0 {
+		return nil
+	}
+
+	changed, moved := false, false
+	if position.BestPrice <= 0 || position.Better(price, position.BestPrice) {
+		position.BestPrice = price
+		changed = true
+	}
+
+	if channel, ok := m.channels[position.Channel]; ok {
+		now := time.Now()
+		for _, rule := range channel.StopRules {
+			stop, err := rule.Stop(ctx, exchangeClient, position, now)
+			if err != nil {
+				log.WithError(err).Warn("Failed to apply stop rule")
+
+				continue
+			}
+			if stop <= 0 || (position.Stop > 0 && !position.Better(stop, position.Stop)) {
+				continue
+			}
+
+			log.
+				WithFields(logrus.Fields{
+					"From": position.Stop,
+					"To":   stop,
+				}).
+				Info("Position stop moved")
+			position.Stop = stop
+			position.StopMoved = true
+			changed, moved = true, true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := m.orderRepository.Update(ctx, position); err != nil {
+		return err
+	}
+
+	if stopLossClient, ok := exchangeClient.(client.StopLossClient); ok && moved {
+		if err := stopLossClient.SetStopLoss(ctx, position.Symbol, position.BaseSymbol, position.Stop); err != nil {
+			log.WithError(err).Warn("Failed to move stop loss on exchange, position is closed on stop by manager")
+		}
+	}
+
+	return nil
+}
+
+// takeTargets closes a slice of position on every target price got to and closes the rest on the last one.
+// Every slice is claimed in storage before it is sent, so it is never sent twice or after position is stopped,
+// and released when exchange didn't take it, so it is sent again on the next check.
+func (m *Manager) takeTargets(ctx context.Context, exchangeClient client.Client, position *types.Order, price float64, log *logrus.Entry) error {
+	for position.TargetReached(price) {
+		target := position.NextTarget()
+		if position.TargetsHit == len(position.Targets)-1 {
+			log.WithField("Target", target.Price).Info("Position last target reached")
+
+			return m.closePosition(ctx, exchangeClient, position, price, log)
+		}
+
+		quantity, err := m.exitQuantity(ctx, exchangeClient, position, position.NextTargetQuantity())
+		if err != nil {
+			return err
+		}
+		if err := m.orderRepository.TakeTarget(ctx, position, quantity); err != nil {
+			return err
+		}
+
+		// nothing is filled yet or slice is too small, the rest is closed on the last target
+		if quantity > 0 {
+			if err := m.closePart(ctx, exchangeClient, position, quantity); err != nil {
+				if releaseErr := m.orderRepository.ReleaseTarget(ctx, position, quantity); releaseErr != nil {
+					log.WithError(releaseErr).Error("Failed to release target not taken on exchange")
+				}
+
+				return err
+			}
+
+			position.RealisedPnl += position.Pnl(price, quantity)
+			if err := m.orderRepository.Update(ctx, position); err != nil {
+				return err
+			}
+		}
+		log.
+			WithFields(logrus.Fields{
+				"Target":   target.Price,
+				"Quantity": quantity,
+			}).
+			Info("Position target taken")
+	}
+
+	return nil
+}
+
+// closePosition claims position by closing status and sends exit order for all of it not closed yet.
+// Claim fails when position status was changed by anyone else, so position is exited only once.
+func (m *Manager) closePosition(ctx context.Context, exchangeClient client.Client, position *types.Order, price float64, log *logrus.Entry) error {
+	if position.Status != types.OrderStatusClosing {
+		if err := m.orderStates.Transition(ctx, position, types.OrderStatusClosing); err != nil {
+			return err
+		}
+	}
+
+	// targets taken before the claim are known only to storage
+	claimed, err := m.orderRepository.Get(ctx, position.UUID)
+	if err != nil {
+		return err
+	}
+	if err := m.refreshFill(ctx, exchangeClient, claimed, log); err != nil {
+		return err
+	}
+
+	quantity, err := m.exitQuantity(ctx, exchangeClient, claimed, claimed.OpenQuantity())
+	if err != nil {
+		return err
+	}
+	if quantity > 0 {
+		// exit is saved before it is sent, so close retried after a failed save doesn't send it again
+		pnl := claimed.Pnl(price, quantity)
+		claimed.ClosedQuantity += quantity
+		claimed.RealisedPnl += pnl
+		if err := m.orderRepository.Update(ctx, claimed); err != nil {
+			return err
+		}
+
+		if err := m.closePart(ctx, exchangeClient, claimed, quantity); err != nil {
+			claimed.ClosedQuantity -= quantity
+			claimed.RealisedPnl -= pnl
+			if rollbackErr := m.orderRepository.Update(ctx, claimed); rollbackErr != nil {
+				log.WithError(rollbackErr).Error("Failed to give back exit not sent to exchange")
+			}
+
+			return err
+		}
+	}
+
+	if err := m.orderStates.Transition(ctx, claimed, types.OrderStatusClosed); err != nil {
+		return err
+	}
+	log.
+		WithFields(logrus.Fields{
+			"Quantity":    quantity,
+			"RealisedPnl": claimed.RealisedPnl,
+		}).
+		Info("Position closed")
+
+	return nil
+}
+
+// refreshFill reads fills of entry legs from exchange and sums them into position. Reconciler
+// doesn't visit closed position, so leg filled before its cancel reached exchange is counted here
+// or never. Leg exchange can't report keeps fill it is stored with.
+func (m *Manager) refreshFill(ctx context.Context, exchangeClient client.Client, position *types.Order, log *logrus.Entry) error {
+	legs, err := m.orderRepository.FindLegs(ctx, position.UUID)
+	if err != nil {
+		return err
+	}
+	if len(legs) == 0 {
+		return nil
+	}
+
+	for _, leg := range legs {
+		if leg.ExchangeOrderID == "" {
+			continue
+		}
+
+		legLog := log.WithFields(logrus.Fields{
+			"Leg":             leg.Leg,
+			"ExchangeOrderID": leg.ExchangeOrderID,
+		})
+		exchangeOrder, err := exchangeClient.GetOrder(ctx, leg.Symbol, leg.BaseSymbol, leg.ExchangeOrderID)
+		if err != nil {
+			legLog.WithError(err).Warn("Failed to refresh fill of entry leg, stored fill is exited")
+
+			continue
+		}
+		if !copyFill(leg, exchangeOrder) {
+			continue
+		}
+		if err := m.orderRepository.UpdateFill(ctx, leg); err != nil {
+			return err
+		}
+		legLog.WithField("FilledQuantity", leg.FilledQuantity).Info("Entry leg fill changed before position is closed")
+	}
+
+	if !sumFills(position, legs) {
+		return nil
+	}
+
+	return m.orderRepository.UpdateFill(ctx, position)
+}
+
+// openOnExchange reports whether exchange still holds position of the same side
+func (m *Manager) openOnExchange(ctx context.Context, futuresClient client.FuturesClient, position *types.Order) (bool, error) {
+	positions, err := futuresClient.GetPositions(ctx, position.Symbol, position.BaseSymbol)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range positions {
+		if p.Position == position.Position && p.Quantity > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// settlePosition closes in storage position exchange has closed already, so no exit order is sent.
+// Exit price isn't known, position is taken as exited at its stop once price is past it and at current price otherwise.
+func (m *Manager) settlePosition(ctx context.Context, exchangeClient client.Client, position *types.Order, price float64, log *logrus.Entry) error {
+	if err := m.cancelLegs(ctx, exchangeClient, position, types.OrderStatusCancelled, log); err != nil {
+		return err
+	}
+
+	if position.Status != types.OrderStatusClosing {
+		if err := m.orderStates.Transition(ctx, position, types.OrderStatusClosing); err != nil {
+			return err
+		}
+	}
+
+	claimed, err := m.orderRepository.Get(ctx, position.UUID)
+	if err != nil {
+		return err
+	}
+
+	exit := price
+	if claimed.StopReached(price) {
+		exit = claimed.Stop
+	}
+	claimed.RealisedPnl += claimed.Pnl(exit, claimed.OpenQuantity())
+	claimed.ClosedQuantity = claimed.HeldQuantity()
+	if err := m.orderRepository.Update(ctx, claimed); err != nil {
+		return err
+	}
+
+	if err := m.orderStates.Transition(ctx, claimed, types.OrderStatusClosed); err != nil {
+		return err
+	}
+	log.
+		WithFields(logrus.Fields{
+			"Exit":        exit,
+			"RealisedPnl": claimed.RealisedPnl,
+		}).
+		Info("Position closed on exchange")
+
+	return nil
+}
+
+// exitQuantity fits quantity to free spot balance and symbol filters, quantity exchange doesn't accept is not sent at all
+func (m *Manager) exitQuantity(ctx context.Context, exchangeClient client.Client, position *types.Order, quantity float64) (float64, error) {
+	if quantity <= 0 {
+		return quantity, nil
+	}
+
+	// spot balance may hold less than position, e.g. when fee is charged in traded asset unknown yet
+	if _, ok := exchangeClient.(client.FuturesClient); !ok {
+		free, err := exchangeClient.GetAssets(ctx, position.Symbol)
+		if err != nil {
+			return 0, err
+		}
+		quantity = math.Min(quantity, free)
+	}
+
+	if m.normalizer == nil || quantity <= 0 {
+		return quantity, nil
+	}
+
+	_, normalized, err := m.normalizer.Normalize(ctx, exchangeClient, position.Symbol, position.BaseSymbol, 0, quantity, 0)
+	if errors.Is(err, types.ErrOrderBelowMinimum) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return normalized, nil
+}
+
+// closePart sends market order closing quantity of position
+func (m *Manager) closePart(ctx context.Context, exchangeClient client.Client, position *types.Order, quantity float64) error {
+	switch c := exchangeClient.(type) {
+	case client.FuturesClient:
+		_, err := c.CreateFuturesOrder(ctx, &clientTypes.FuturesOrder{
+			Exchange:   position.Exchange,
+			Type:       commonTypes.OrderTypeMarket,
+			Position:   position.Position,
+			Symbol:     position.Symbol,
+			BaseSymbol: position.BaseSymbol,
+			Quantity:   quantity,
+			Leverage:   position.Leverage,
+			ReduceOnly: true,
+		})
+
+		return err
+	case client.SpotClient:
+		// spot position is always long, it is closed by selling
+		_, err := c.CreateSpotOrder(ctx, &clientTypes.SpotOrder{
+			Exchange:   position.Exchange,
+			Type:       commonTypes.OrderTypeMarket,
+			Position:   commonTypes.PositionShort,
+			Symbol:     position.Symbol,
+			BaseSymbol: position.BaseSymbol,
+			Quantity:   quantity,
+		})
+
+		return err
+	default:
+		return types.ErrOrderTypeNotSupported
+	}
+}
+
+// entryExpired reports whether entry legs of position wait for fill longer than entry TTL of its channel
+func (m *Manager) entryExpired(position *types.Order) bool {
+	if position.Status != types.OrderStatusPlaced && position.Status != types.OrderStatusPartiallyFilled {
+		return false
+	}
+
+	channel, ok := m.channels[position.Channel]
+	if !ok || channel.EntryTTL <= 0 {
+		return false
+	}
+
+	return time.Since(position.CreatedAt) >= channel.EntryTTL
+}
+
+// cancelLegs cancels entry legs of position still waiting on exchange and moves them to status telling why
+func (m *Manager) cancelLegs(ctx context.Context, exchangeClient client.Client, position *types.Order, status types.OrderStatus, log *logrus.Entry) error {
+	legs, err := m.orderRepository.FindLegs(ctx, position.UUID)
+	if err != nil {
+		return err
+	}
+
+	for _, leg := range legs {
+		if leg.Status != types.OrderStatusPlaced && leg.Status != types.OrderStatusPartiallyFilled {
+			continue
+		}
+
+		legLog := log.WithFields(logrus.Fields{
+			"Leg":             leg.Leg,
+			"ExchangeOrderID": leg.ExchangeOrderID,
+		})
+		if leg.ExchangeOrderID == "" {
+			legLog.Warn("Entry leg has no exchange order id, it can't be cancelled")
+
+			continue
+		}
+
+		if err := exchangeClient.CancelOrder(ctx, leg.Symbol, leg.BaseSymbol, leg.ExchangeOrderID); err != nil {
+			legLog.WithError(err).Error("Failed to cancel entry leg")
+
+			continue
+		}
+
+		if err := m.orderStates.Transition(ctx, leg, status); err != nil {
+			legLog.WithError(err).Error("Failed to mark entry leg as cancelled")
+
+			continue
+		}
+		legLog.WithField("Status", status).Info("Entry leg cancelled")
+	}
+
+	return nil
+}
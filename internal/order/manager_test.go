@@ -0,0 +1,558 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order"
+	"trade_bot/internal/order/exit"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// stopLossClient is a futures exchange keeping stop loss of position it is moved to
+type stopLossClient struct {
+	*futuresClient
+	stops []float64
+}
+
+func (s *stopLossClient) SetStopLoss(_ context.Context, _, _ string, stop float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stops = append(s.stops, stop)
+
+	return nil
+}
+
+func (s *stopLossClient) movedStops() []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]float64(nil), s.stops...)
+}
+
+func newTestManager(repository *memoryRepository, exchangeClient client.Client, stopRules ...order.StopRule) *order.Manager {
+	return newChannelTestManager(repository, exchangeClient, &order.ChannelOptions{StopRules: stopRules})
+}
+
+func newChannelTestManager(repository *memoryRepository, exchangeClient client.Client, channel *order.ChannelOptions) *order.Manager {
+	return order.NewManager(&order.ManagerOptions{
+		Channels: map[commonTypes.SignalChannel]*order.ChannelOptions{
+			commonTypes.SignalChannelHardcoreVIP: channel,
+		},
+		Clients:         client.NewRegistry(exchangeClient),
+		OrderRepository: repository,
+		OrderStates:     newTestStates(repository),
+		Interval:        10 * time.Millisecond,
+		Logger:          logrus.New(),
+	})
+}
+
+func TestManagerTakesTargetsInSlices(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets: []types.Target{
+			{Price: 19, Percent: 50},
+			{Price: 20, Percent: 30},
+			{Price: 21, Percent: 20},
+		},
+		Stop:   17,
+		Status: types.OrderStatusPlaced,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &spotClient{price: 20.5}
+
+	manager := newTestManager(repository, exchangeClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = manager.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).TargetsHit == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []float64{5, 3}, exchangeClient.quantities())
+
+	exchangeClient.setPrice(21)
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+	assert.InDeltaSlice(t, []float64{5, 3, 2}, exchangeClient.quantities(), 1e-9)
+	assert.InDelta(t, 5*2.5+3*2.5+2*3, repository.get(position.UUID).RealisedPnl, 1e-9)
+}
+
+func TestManagerGivesBackExitsNotSent(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets:        []types.Target{{Price: 19, Percent: 50}, {Price: 20, Percent: 50}},
+		Stop:           17,
+		Status:         types.OrderStatusFilled,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &spotClient{price: 19.5, createErr: errors.New("connection reset")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient).Start(ctx)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	stored := repository.get(position.UUID)
+	assert.Equal(t, 0, stored.TargetsHit)
+	assert.Equal(t, 0.0, stored.ClosedQuantity)
+
+	exchangeClient.setCreateErr(nil)
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).TargetsHit == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// stop exit failing is sent again in full
+	exchangeClient.setCreateErr(errors.New("connection reset"))
+	exchangeClient.setPrice(16.5)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, types.OrderStatusClosing, repository.get(position.UUID).Status)
+	assert.Equal(t, 5.0, repository.get(position.UUID).ClosedQuantity)
+
+	exchangeClient.setCreateErr(nil)
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []float64{5, 5}, exchangeClient.quantities())
+	assert.InDelta(t, 5*1.5+5*-1.5, repository.get(position.UUID).RealisedPnl, 1e-9)
+}
+
+func TestManagerSellsSpotOnStop(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets:        []types.Target{{Price: 19, Percent: 50}, {Price: 20, Percent: 50}},
+		Stop:           17,
+		TargetsHit:     1,
+		ClosedQuantity: 5,
+		Status:         types.OrderStatusPlaced,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &spotClient{price: 16.5}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient).Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+
+	// only what is left after the first target is sold, and only once
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, []float64{5}, exchangeClient.quantities())
+	if sent := exchangeClient.sent(); assert.Len(t, sent, 1) {
+		assert.Equal(t, commonTypes.OrderTypeMarket, sent[0].Type)
+		assert.Equal(t, commonTypes.PositionShort, sent[0].Position)
+	}
+}
+
+func TestManagerSellsLegFilledBeforeCancel(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 5,
+		AveragePrice:   18,
+		Targets:        []types.Target{{Price: 20, Percent: 100}},
+		Stop:           17,
+		Status:         types.OrderStatusPartiallyFilled,
+	}
+	filled := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Leg: 0, ExchangeOrderID: "1", Quantity: 5, FilledQuantity: 5, AveragePrice: 18, Status: types.OrderStatusFilled}
+	waiting := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Leg: 1, ExchangeOrderID: "2", Quantity: 5, Status: types.OrderStatusPlaced}
+
+	repository := newMemoryRepository(position, filled, waiting)
+	// second leg is filled since the last reconcile
+	exchangeClient := &spotClient{price: 16.5, fills: map[commonTypes.OrderID]*commonTypes.Order{
+		"1": {Status: commonTypes.OrderStatusFilled, ExecutedQuantity: 5, AveragePrice: 18},
+		"2": {Status: commonTypes.OrderStatusFilled, ExecutedQuantity: 5, AveragePrice: 17.5},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient).Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []float64{10}, exchangeClient.quantities())
+	stored := repository.get(position.UUID)
+	assert.Equal(t, 10.0, stored.FilledQuantity)
+	assert.Equal(t, 17.75, stored.AveragePrice)
+	assert.Equal(t, 5.0, repository.get(waiting.UUID).FilledQuantity)
+}
+
+func TestManagerSellsQuantityLeftAfterFee(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		QuantityFee:    0.02,
+		Targets:        []types.Target{{Price: 19, Percent: 50}, {Price: 20, Percent: 50}},
+		Stop:           17,
+		Status:         types.OrderStatusFilled,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &spotClient{price: 19.5}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient).Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).TargetsHit == 1
+	}, time.Second, 10*time.Millisecond)
+
+	exchangeClient.setPrice(16.5)
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+
+	// fee taken in ETC is never held, so it is never sold
+	assert.InDeltaSlice(t, []float64{4.99, 4.99}, exchangeClient.quantities(), 1e-9)
+}
+
+func TestManagerSellsNoMoreThanFreeBalance(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets:        []types.Target{{Price: 19, Percent: 100}},
+		Stop:           17,
+		Status:         types.OrderStatusFilled,
+	}
+
+	repository := newMemoryRepository(position)
+	// fee charged in ETC is not reported by exchange yet
+	exchangeClient := &spotClient{price: 16.5, free: 9.98}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient).Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []float64{9.98}, exchangeClient.quantities())
+}
+
+func TestManagerSettlesFuturesClosedOnExchange(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets:        []types.Target{{Price: 19, Percent: 50}, {Price: 20, Percent: 50}},
+		Stop:           17,
+		TargetsHit:     1,
+		ClosedQuantity: 5,
+		Status:         types.OrderStatusFilled,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &futuresClient{spotClient: &spotClient{price: 16.5}}
+	exchangeClient.setPositions(&clientTypes.OpenPosition{Position: commonTypes.PositionLong, Quantity: 5})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient).Start(ctx)
+	}()
+
+	// signal stop is left to exchange while it holds position
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, types.OrderStatusFilled, repository.get(position.UUID).Status)
+
+	exchangeClient.setPositions()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+
+	closed := repository.get(position.UUID)
+	assert.Equal(t, 10.0, closed.ClosedQuantity)
+	assert.InDelta(t, -5.0, closed.RealisedPnl, 1e-9)
+	assert.Empty(t, exchangeClient.sent())
+}
+
+func TestManagerSettlesFuturesAtPriceOffStop(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionShort,
+		Entry:          20,
+		AveragePrice:   20,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets:        []types.Target{{Price: 18, Percent: 100}},
+		Stop:           21,
+		Status:         types.OrderStatusFilled,
+	}
+
+	// take profit kept on exchange closed position before manager saw the target
+	repository := newMemoryRepository(position)
+	exchangeClient := &futuresClient{spotClient: &spotClient{price: 17.5}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient).Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+
+	closed := repository.get(position.UUID)
+	assert.Equal(t, 10.0, closed.ClosedQuantity)
+	assert.InDelta(t, 25.0, closed.RealisedPnl, 1e-9)
+	assert.Empty(t, exchangeClient.sent())
+}
+
+func TestManagerMovesStopToBreakEven(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Channel:        commonTypes.SignalChannelHardcoreVIP,
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets:        []types.Target{{Price: 19, Percent: 50}, {Price: 20, Percent: 50}},
+		Stop:           17,
+		TargetsHit:     1,
+		ClosedQuantity: 5,
+		Status:         types.OrderStatusPlaced,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &spotClient{price: 18.5}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient, exit.NewBreakEven(1)).Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).StopMoved
+	}, time.Second, 10*time.Millisecond)
+	stored := repository.get(position.UUID)
+	assert.Equal(t, 18.0, stored.Stop)
+	assert.Equal(t, 18.5, stored.BestPrice)
+
+	exchangeClient.setPrice(17.9)
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusClosed
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []float64{5}, exchangeClient.quantities())
+}
+
+func TestManagerKeepsStopBeforeFill(t *testing.T) {
+	position := types.Order{
+		UUID:       uuid.New(),
+		Channel:    commonTypes.SignalChannelHardcoreVIP,
+		Exchange:   commonTypes.ExchangeMexc,
+		Symbol:     "ETC",
+		BaseSymbol: "USDT",
+		Position:   commonTypes.PositionLong,
+		Entry:      20,
+		Quantity:   10,
+		Targets:    []types.Target{{Price: 30, Percent: 100}},
+		Stop:       18,
+		Status:     types.OrderStatusPlaced,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &spotClient{price: 25}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient, exit.NewTrailingPercent(10)).Start(ctx)
+	}()
+
+	// price ran up while entry waits for fill
+	time.Sleep(50 * time.Millisecond)
+	stored := repository.get(position.UUID)
+	assert.Equal(t, 18.0, stored.Stop)
+	assert.False(t, stored.StopMoved)
+	assert.Zero(t, stored.BestPrice)
+}
+
+func TestManagerMovesStopLossOnExchange(t *testing.T) {
+	position := types.Order{
+		UUID:           uuid.New(),
+		Channel:        commonTypes.SignalChannelHardcoreVIP,
+		Exchange:       commonTypes.ExchangeMexc,
+		Symbol:         "ETC",
+		BaseSymbol:     "USDT",
+		Position:       commonTypes.PositionLong,
+		Entry:          18,
+		Quantity:       10,
+		FilledQuantity: 10,
+		Targets:        []types.Target{{Price: 19, Percent: 50}, {Price: 20, Percent: 50}},
+		Stop:           17,
+		TargetsHit:     1,
+		ClosedQuantity: 5,
+		Status:         types.OrderStatusFilled,
+	}
+
+	repository := newMemoryRepository(position)
+	exchangeClient := &stopLossClient{futuresClient: &futuresClient{spotClient: &spotClient{price: 18.5}}}
+	exchangeClient.setPositions(&clientTypes.OpenPosition{Position: commonTypes.PositionLong, Quantity: 5})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = newTestManager(repository, exchangeClient, exit.NewBreakEven(1)).Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).StopMoved
+	}, time.Second, 10*time.Millisecond)
+
+	// stop is moved on exchange once, while it stays the same
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, []float64{18}, exchangeClient.movedStops())
+	assert.Empty(t, exchangeClient.sent())
+}
+
+func TestManagerCancelsWaitingEntry(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		price     float64
+		status    types.OrderStatus
+	}{
+		{"entry TTL passed", time.Now().Add(-2 * time.Hour), 18.5, types.OrderStatusExpired},
+		{"target reached before fill", time.Now(), 19.5, types.OrderStatusMissed},
+		{"entry still waits", time.Now(), 18.5, types.OrderStatusPlaced},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			position := types.Order{
+				UUID:       uuid.New(),
+				Channel:    commonTypes.SignalChannelHardcoreVIP,
+				CreatedAt:  test.createdAt,
+				Exchange:   commonTypes.ExchangeMexc,
+				Symbol:     "ETC",
+				BaseSymbol: "USDT",
+				Position:   commonTypes.PositionLong,
+				Entry:      18,
+				Quantity:   10,
+				Targets:    []types.Target{{Price: 19, Percent: 100}},
+				Stop:       17,
+				Status:     types.OrderStatusPlaced,
+			}
+			leg := types.Order{
+				UUID:            uuid.New(),
+				ParentUUID:      position.UUID,
+				Exchange:        position.Exchange,
+				Symbol:          position.Symbol,
+				BaseSymbol:      position.BaseSymbol,
+				ExchangeOrderID: "1",
+				Quantity:        10,
+				Status:          types.OrderStatusPlaced,
+			}
+
+			repository := newMemoryRepository(position, leg)
+			exchangeClient := &spotClient{price: test.price}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = newChannelTestManager(repository, exchangeClient, &order.ChannelOptions{EntryTTL: time.Hour}).Start(ctx)
+			}()
+
+			if test.status == types.OrderStatusPlaced {
+				time.Sleep(30 * time.Millisecond)
+			} else {
+				require.Eventually(t, func() bool {
+					return repository.get(leg.UUID).Status == test.status
+				}, time.Second, 10*time.Millisecond)
+			}
+
+			// position ends once reconciler sees its legs are done, nothing is entered to close
+			time.Sleep(30 * time.Millisecond)
+			assert.Equal(t, test.status, repository.get(leg.UUID).Status)
+			assert.Equal(t, types.OrderStatusPlaced, repository.get(position.UUID).Status)
+			assert.Empty(t, exchangeClient.quantities())
+		})
+	}
+}
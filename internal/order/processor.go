@@ -4,13 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/sirupsen/logrus"
 
+	"trade_bot/internal/order/types"
 	signalTypes "trade_bot/internal/signals/types"
 )
 
+const (
+	processorDefaultRetryDelay = 2 * time.Second
+	processorDefaultMaxRetries = 5
+)
+
 type orderHandler interface {
 	ProcessSignal(ctx context.Context, signal *signalTypes.Signal) error
 }
@@ -18,21 +25,46 @@ type orderHandler interface {
 type ProcessorOptions struct {
 	SignalTopic      string
 	SignalSubscriber message.Subscriber
-	Logger           *logrus.Logger
+	OrderHandler     orderHandler
+	// RetryDelay is the wait before signal failed transiently is delivered again, it doubles with every retry
+	RetryDelay time.Duration
+	// MaxRetries limits how many times signal is delivered again before it is dropped
+	MaxRetries int
+	Logger     *logrus.Logger
 }
 
 type Processor struct {
 	signalTopic      string
 	signalSubscriber message.Subscriber
 	orderHandler     orderHandler
+	retryDelay       time.Duration
+	maxRetries       int
 	log              *logrus.Logger
+
+	// retries counts deliveries of signal message failed transiently
+	retries map[string]int
 }
 
+// NewProcessor creates a new signal processor. Empty retry options are replaced with defaults.
 func NewProcessor(opt *ProcessorOptions) *Processor {
+	retryDelay := opt.RetryDelay
+	if retryDelay == 0 {
+		retryDelay = processorDefaultRetryDelay
+	}
+
+	maxRetries := opt.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = processorDefaultMaxRetries
+	}
+
 	return &Processor{
 		signalTopic:      opt.SignalTopic,
 		signalSubscriber: opt.SignalSubscriber,
+		orderHandler:     opt.OrderHandler,
+		retryDelay:       retryDelay,
+		maxRetries:       maxRetries,
 		log:              opt.Logger,
+		retries:          map[string]int{},
 	}
 }
 
@@ -67,6 +99,7 @@ func (p *Processor) Start(ctx context.Context) error {
 					WithError(err).
 					Error("Failed to unmarshal incoming message")
 
+				rawMsg.Ack()
 				continue
 			}
 
@@ -78,13 +111,36 @@ func (p *Processor) Start(ctx context.Context) error {
 			})
 			log.Debug("Processing incoming signal")
 
-			// process signal to order
+			// process signal to order, signal failed transiently is delivered again after backoff
 			if err := p.orderHandler.ProcessSignal(ctx, &msg); err != nil {
+				retry := p.retries[rawMsg.UUID]
+				if types.IsRetryable(err) && retry < p.maxRetries {
+					p.retries[rawMsg.UUID] = retry + 1
+					delay := p.retryDelay << retry
+					log.
+						WithError(err).
+						WithFields(logrus.Fields{
+							"Retry": retry + 1,
+							"Delay": delay,
+						}).
+						Warn("Failed to process signal into order, it is delivered again")
+
+					select {
+					case <-ctx.Done():
+						p.log.Info("Processor context cancelled, stopping order processing")
+						return nil
+					case <-time.After(delay):
+					}
+
+					rawMsg.Nack()
+					continue
+				}
+
+				// signal that can't become order is dropped, later signals must not wait for it
 				log.WithError(err).Error("Failed to process signal into order")
-
-				continue
 			}
 
+			delete(p.retries, rawMsg.UUID)
 			rawMsg.Ack()
 		}
 	}
@@ -0,0 +1,156 @@
+package order_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/order"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+const testSignalTopic = "signal.created"
+
+// signalHandler fails every signal with the errors queued for it and reports each processing
+type signalHandler struct {
+	errs      map[uuid.UUID][]error
+	processed chan uuid.UUID
+}
+
+func newSignalHandler() *signalHandler {
+	return &signalHandler{
+		errs:      map[uuid.UUID][]error{},
+		processed: make(chan uuid.UUID, 16),
+	}
+}
+
+func (s *signalHandler) ProcessSignal(_ context.Context, signal *signalTypes.Signal) error {
+	s.processed <- signal.UUID
+
+	errs := s.errs[signal.UUID]
+	if len(errs) == 0 {
+		return nil
+	}
+	s.errs[signal.UUID] = errs[1:]
+
+	return errs[0]
+}
+
+// next waits for the next signal handler processes
+func (s *signalHandler) next(t *testing.T) uuid.UUID {
+	t.Helper()
+
+	select {
+	case signalUUID := <-s.processed:
+		return signalUUID
+	case <-time.After(time.Second):
+		t.Fatal("signal is not processed")
+		return uuid.Nil
+	}
+}
+
+func startTestProcessor(t *testing.T, handler *signalHandler) message.Publisher {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	processor := order.NewProcessor(&order.ProcessorOptions{
+		SignalTopic:      testSignalTopic,
+		SignalSubscriber: pubSub,
+		OrderHandler:     handler,
+		RetryDelay:       time.Millisecond,
+		MaxRetries:       2,
+		Logger:           logrus.New(),
+	})
+	go func() {
+		assert.NoError(t, processor.Start(ctx))
+	}()
+
+	return pubSub
+}
+
+func publishSignal(t *testing.T, publisher message.Publisher, signal *signalTypes.Signal) {
+	t.Helper()
+
+	rawMessage, err := json.Marshal(signal)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(testSignalTopic, message.NewMessage(watermill.NewUUID(), rawMessage)))
+}
+
+func TestProcessorDropsSignalFailedPermanently(t *testing.T) {
+	handler := newSignalHandler()
+	failed, next := signalTypes.NewSignal(), signalTypes.NewSignal()
+	handler.errs[failed.UUID] = []error{types.ErrOrderChannelNotFound}
+
+	publisher := startTestProcessor(t, handler)
+	publishSignal(t, publisher, failed)
+	assert.Equal(t, failed.UUID, handler.next(t))
+
+	// failed signal is not delivered again and doesn't hold the next one
+	publishSignal(t, publisher, next)
+	assert.Equal(t, next.UUID, handler.next(t))
+	assert.Empty(t, handler.processed)
+}
+
+func TestProcessorSkipsMalformedSignal(t *testing.T) {
+	handler := newSignalHandler()
+	next := signalTypes.NewSignal()
+
+	publisher := startTestProcessor(t, handler)
+	require.NoError(t, publisher.Publish(testSignalTopic, message.NewMessage(watermill.NewUUID(), []byte("{"))))
+	publishSignal(t, publisher, next)
+
+	assert.Equal(t, next.UUID, handler.next(t))
+}
+
+func TestProcessorRetriesPendingSignal(t *testing.T) {
+	handler := newSignalHandler()
+	pending, next := signalTypes.NewSignal(), signalTypes.NewSignal()
+	handler.errs[pending.UUID] = []error{
+		fmt.Errorf("Handler::ProcessSignal : %w", types.ErrOrderLegsPending),
+		fmt.Errorf("Handler::ProcessSignal : %w", types.ErrOrderLegsPending),
+	}
+
+	publisher := startTestProcessor(t, handler)
+	publishSignal(t, publisher, pending)
+
+	// legs are placed on the third delivery
+	for range 3 {
+		assert.Equal(t, pending.UUID, handler.next(t))
+	}
+
+	publishSignal(t, publisher, next)
+	assert.Equal(t, next.UUID, handler.next(t))
+	assert.Empty(t, handler.processed)
+}
+
+func TestProcessorDropsSignalOutOfRetries(t *testing.T) {
+	handler := newSignalHandler()
+	pending, next := signalTypes.NewSignal(), signalTypes.NewSignal()
+	handler.errs[pending.UUID] = []error{types.ErrOrderLegsPending, types.ErrOrderLegsPending, types.ErrOrderLegsPending, types.ErrOrderLegsPending}
+
+	publisher := startTestProcessor(t, handler)
+	publishSignal(t, publisher, pending)
+
+	// delivered once and retried twice
+	for range 3 {
+		assert.Equal(t, pending.UUID, handler.next(t))
+	}
+
+	publishSignal(t, publisher, next)
+	assert.Equal(t, next.UUID, handler.next(t))
+	assert.Empty(t, handler.processed)
+}
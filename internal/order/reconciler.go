@@ -0,0 +1,343 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type reconcilerRepository interface {
+	FindPositions(ctx context.Context, statuses ...types.OrderStatus) ([]*types.Order, error)
+	FindLegs(ctx context.Context, parentUUID uuid.UUID) ([]*types.Order, error)
+	UpdateFill(ctx context.Context, order *types.Order) error
+}
+
+type ReconcilerOptions struct {
+	Clients         clientRegistry
+	OrderRepository reconcilerRepository
+	OrderStates     orderStates
+	// Interval is how often orders are compared with exchange
+	Interval time.Duration
+	Logger   *logrus.Logger
+}
+
+// Reconciler keeps orders in storage in line with exchange. It follows fills of entry legs
+// and sums them up into their positions. Legs left pending by a failed send are looked up
+// on exchange by client order id and adopted or rejected.
+type Reconciler struct {
+	clients         clientRegistry
+	orderRepository reconcilerRepository
+	orderStates     orderStates
+	interval        time.Duration
+	log             *logrus.Logger
+}
+
+func NewReconciler(opt *ReconcilerOptions) *Reconciler {
+	return &Reconciler{
+		clients:         opt.Clients,
+		orderRepository: opt.OrderRepository,
+		orderStates:     opt.OrderStates,
+		interval:        opt.Interval,
+		log:             opt.Logger,
+	}
+}
+
+func (r *Reconciler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.log.Info("Reconciler context cancelled, stopping order reconciliation")
+			return nil
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+func (r *Reconciler) Stop(ctx context.Context) error {
+	r.log.Info("Stopping order reconciler")
+
+	return nil
+}
+
+func (r *Reconciler) check(ctx context.Context) {
+	positions, err := r.orderRepository.FindPositions(
+		ctx,
+		types.OrderStatusPlaced,
+		types.OrderStatusPartiallyFilled,
+		types.OrderStatusFilled,
+		types.OrderStatusClosing,
+	)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to find open positions")
+
+		return
+	}
+
+	for _, position := range positions {
+		log := r.log.WithFields(logrus.Fields{
+			"OrderUUID": position.UUID,
+			"Exchange":  position.Exchange,
+			"Symbol":    position.Symbol,
+		})
+
+		if err := r.reconcilePosition(ctx, position, log); err != nil {
+			log.WithError(err).Error("Failed to reconcile position")
+		}
+	}
+}
+
+func (r *Reconciler) reconcilePosition(ctx context.Context, position *types.Order, log *logrus.Entry) error {
+	exchangeClient, err := r.clients.Get(position.Exchange)
+	if err != nil {
+		return fmt.Errorf("Reconciler::reconcilePosition : %w", err)
+	}
+
+	legs, err := r.orderRepository.FindLegs(ctx, position.UUID)
+	if err != nil {
+		return fmt.Errorf("Reconciler::reconcilePosition : %w", err)
+	}
+
+	for _, leg := range legs {
+		legLog := log.WithFields(logrus.Fields{
+			"Leg":           leg.Leg,
+			"ClientOrderID": leg.ClientOrderID,
+		})
+
+		// position is placed, so handler is done with its legs and pending one won't be sent again
+		if leg.Status == types.OrderStatusPending {
+			if err := r.resolvePending(ctx, exchangeClient, leg, legLog); err != nil {
+				legLog.WithError(err).Error("Failed to resolve pending entry leg")
+			}
+		}
+
+		// cancelled leg may still have been filled before cancel reached exchange
+		if leg.Status != types.OrderStatusPlaced &&
+			leg.Status != types.OrderStatusPartiallyFilled &&
+			leg.Status != types.OrderStatusCancelled &&
+			leg.Status != types.OrderStatusExpired &&
+			leg.Status != types.OrderStatusMissed {
+			continue
+		}
+
+		legLog = legLog.WithField("ExchangeOrderID", leg.ExchangeOrderID)
+		if leg.ExchangeOrderID == "" {
+			legLog.Debug("Entry leg has no exchange order id, it can't be reconciled")
+
+			continue
+		}
+
+		if err := r.reconcileLeg(ctx, exchangeClient, leg, legLog); err != nil {
+			legLog.WithError(err).Error("Failed to reconcile entry leg")
+		}
+	}
+
+	if err := r.sumLegs(ctx, position, legs, log); err != nil {
+		return fmt.Errorf("Reconciler::reconcilePosition : %w", err)
+	}
+
+	return nil
+}
+
+// resolvePending settles leg it is unknown about whether its send reached exchange. Leg exchange has
+// under its client order id is placed and reconciled from then on, leg exchange doesn't have is rejected.
+func (r *Reconciler) resolvePending(ctx context.Context, exchangeClient client.Client, leg *types.Order, log *logrus.Entry) error {
+	exchangeOrder, err := findOnExchange(ctx, exchangeClient, leg)
+	if err != nil {
+		return err
+	}
+
+	if exchangeOrder == nil {
+		log.Warn("Pending entry leg is not on exchange, it is rejected")
+
+		return r.orderStates.Transition(ctx, leg, types.OrderStatusRejected)
+	}
+
+	leg.ExchangeOrderID = commonTypes.OrderID(exchangeOrder.OrderID)
+	if err := r.orderStates.Transition(ctx, leg, types.OrderStatusPlaced); err != nil {
+		return err
+	}
+	log.
+		WithField("ExchangeOrderID", leg.ExchangeOrderID).
+		Warn("Pending entry leg is found on exchange, it is adopted")
+
+	return nil
+}
+
+// reconcileLeg copies fill of exchange order to leg and moves leg to status exchange reports
+func (r *Reconciler) reconcileLeg(ctx context.Context, exchangeClient client.Client, leg *types.Order, log *logrus.Entry) error {
+	exchangeOrder, err := exchangeClient.GetOrder(ctx, leg.Symbol, leg.BaseSymbol, leg.ExchangeOrderID)
+	if err != nil {
+		return err
+	}
+
+	filled := copyFill(leg, exchangeOrder)
+	if filled {
+		if err := r.orderRepository.UpdateFill(ctx, leg); err != nil {
+			return err
+		}
+		log.
+			WithFields(logrus.Fields{
+				"FilledQuantity": leg.FilledQuantity,
+				"AveragePrice":   leg.AveragePrice,
+				"Fee":            leg.Fee,
+			}).
+			Info("Entry leg fill changed")
+	}
+
+	status := legStatus(leg, exchangeOrder)
+	// partial fill is published on every change of filled quantity
+	if status == leg.Status && !(filled && status == types.OrderStatusPartiallyFilled) {
+		return nil
+	}
+	if !CanTransition(leg.Status, status) {
+		log.
+			WithFields(logrus.Fields{
+				"From": leg.Status,
+				"To":   status,
+			}).
+			Debug("Exchange order status can't be applied to entry leg")
+
+		return nil
+	}
+
+	return r.orderStates.Transition(ctx, leg, status)
+}
+
+// sumLegs sums fills of legs into position and moves position by how its legs are filled.
+// Position with some legs filled and the rest expired, missed or cancelled is deliberately
+// Filled: it holds what was filled and is managed and closed like any other, so its signal
+// is not marked expired or missed.
+func (r *Reconciler) sumLegs(ctx context.Context, position *types.Order, legs []*types.Order, log *logrus.Entry) error {
+	done := true
+	for _, leg := range legs {
+		if leg.Status == types.OrderStatusPending ||
+			leg.Status == types.OrderStatusPlaced ||
+			leg.Status == types.OrderStatusPartiallyFilled {
+			done = false
+		}
+	}
+
+	if sumFills(position, legs) {
+		if err := r.orderRepository.UpdateFill(ctx, position); err != nil {
+			return err
+		}
+		log.
+			WithFields(logrus.Fields{
+				"FilledQuantity": position.FilledQuantity,
+				"AveragePrice":   position.AveragePrice,
+			}).
+			Info("Position fill changed")
+	}
+
+	// position being closed is managed by manager only
+	if position.Status != types.OrderStatusPlaced && position.Status != types.OrderStatusPartiallyFilled {
+		return nil
+	}
+
+	status := position.Status
+	switch {
+	case done && position.FilledQuantity > 0:
+		status = types.OrderStatusFilled
+	case done:
+		status = unfilledStatus(legs)
+	case position.FilledQuantity > 0:
+		status = types.OrderStatusPartiallyFilled
+	}
+	if status == position.Status {
+		return nil
+	}
+
+	return r.orderStates.Transition(ctx, position, status)
+}
+
+// copyFill copies fill exchange reports for order to leg and reports whether it changed
+func copyFill(leg *types.Order, exchangeOrder *commonTypes.Order) bool {
+	if leg.FilledQuantity == exchangeOrder.ExecutedQuantity &&
+		leg.AveragePrice == exchangeOrder.AveragePrice &&
+		leg.Fee == exchangeOrder.Fee &&
+		leg.QuantityFee == exchangeOrder.QuantityFee {
+		return false
+	}
+
+	leg.FilledQuantity = exchangeOrder.ExecutedQuantity
+	leg.AveragePrice = exchangeOrder.AveragePrice
+	leg.Fee = exchangeOrder.Fee
+	leg.QuantityFee = exchangeOrder.QuantityFee
+
+	return true
+}
+
+// sumFills sums fills of legs into position and reports whether fill of position changed
+func sumFills(position *types.Order, legs []*types.Order) bool {
+	filledQuantity, filledCost, fee, quantityFee := 0.0, 0.0, 0.0, 0.0
+	for _, leg := range legs {
+		filledQuantity += leg.FilledQuantity
+		filledCost += leg.FilledQuantity * leg.AveragePrice
+		fee += leg.Fee
+		quantityFee += leg.QuantityFee
+	}
+
+	averagePrice := 0.0
+	if filledQuantity > 0 {
+		averagePrice = filledCost / filledQuantity
+	}
+
+	if position.FilledQuantity == filledQuantity &&
+		position.AveragePrice == averagePrice &&
+		position.Fee == fee &&
+		position.QuantityFee == quantityFee {
+		return false
+	}
+
+	position.FilledQuantity = filledQuantity
+	position.AveragePrice = averagePrice
+	position.Fee = fee
+	position.QuantityFee = quantityFee
+
+	return true
+}
+
+// unfilledStatus tells why position with none of its legs filled is over. Position is missed or
+// expired when its legs are, cancelled otherwise.
+func unfilledStatus(legs []*types.Order) types.OrderStatus {
+	status := types.OrderStatusCancelled
+	for _, leg := range legs {
+		switch leg.Status {
+		case types.OrderStatusMissed:
+			return types.OrderStatusMissed
+		case types.OrderStatusExpired:
+			status = types.OrderStatusExpired
+		}
+	}
+
+	return status
+}
+
+// legStatus maps status of exchange order to status of leg, leg keeps its status when exchange has nothing new
+func legStatus(leg *types.Order, exchangeOrder *commonTypes.Order) types.OrderStatus {
+	switch exchangeOrder.Status {
+	case commonTypes.OrderStatusFilled:
+		return types.OrderStatusFilled
+	case commonTypes.OrderStatusPartiallyFilled:
+		return types.OrderStatusPartiallyFilled
+	case commonTypes.OrderStatusCanceled, commonTypes.OrderStatusPartiallyCanceled:
+		return types.OrderStatusCancelled
+	default:
+		if exchangeOrder.ExecutedQuantity > 0 {
+			return types.OrderStatusPartiallyFilled
+		}
+
+		return leg.Status
+	}
+}
@@ -0,0 +1,219 @@
+package order_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// reconcilerClient is an exchange reporting orders as they are set
+type reconcilerClient struct {
+	client.Client
+	mu     sync.Mutex
+	orders map[commonTypes.OrderID]*commonTypes.Order
+}
+
+func (r *reconcilerClient) Name() commonTypes.Exchange {
+	return commonTypes.ExchangeBybit
+}
+
+func (r *reconcilerClient) GetOrder(_ context.Context, _, _ string, orderID commonTypes.OrderID) (*commonTypes.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o := *r.orders[orderID]
+
+	return &o, nil
+}
+
+func (r *reconcilerClient) GetOrderByClientID(_ context.Context, _, _, clientOrderID string) (*commonTypes.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for orderID, o := range r.orders {
+		if o.ClientOrderID == clientOrderID {
+			found := *o
+			found.OrderID = string(orderID)
+
+			return &found, nil
+		}
+	}
+
+	return nil, client.ErrOrderNotFound
+}
+
+func (r *reconcilerClient) set(orderID commonTypes.OrderID, o *commonTypes.Order) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.orders[orderID] = o
+}
+
+func newTestReconciler(repository *memoryRepository, exchangeClient client.Client) *order.Reconciler {
+	return order.NewReconciler(&order.ReconcilerOptions{
+		Clients:         client.NewRegistry(exchangeClient),
+		OrderRepository: repository,
+		OrderStates:     newTestStates(repository),
+		Interval:        10 * time.Millisecond,
+		Logger:          logrus.New(),
+	})
+}
+
+func TestReconcilerSumsLegFills(t *testing.T) {
+	position := types.Order{
+		UUID:     uuid.New(),
+		Exchange: commonTypes.ExchangeBybit,
+		Symbol:   "ETC",
+		Quantity: 10,
+		Status:   types.OrderStatusPlaced,
+	}
+	first := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Leg: 0, Exchange: position.Exchange, ExchangeOrderID: "1", Quantity: 4, Status: types.OrderStatusPlaced}
+	second := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Leg: 1, Exchange: position.Exchange, ExchangeOrderID: "2", Quantity: 6, Status: types.OrderStatusPlaced}
+
+	repository := newMemoryRepository(position, first, second)
+	exchangeClient := &reconcilerClient{orders: map[commonTypes.OrderID]*commonTypes.Order{
+		"1": {Status: commonTypes.OrderStatusFilled, ExecutedQuantity: 4, AveragePrice: 20, Fee: 0.1},
+		"2": {Status: commonTypes.OrderStatusPartiallyFilled, ExecutedQuantity: 2, AveragePrice: 17, Fee: 0.05},
+	}}
+
+	reconciler := newTestReconciler(repository, exchangeClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = reconciler.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusPartiallyFilled
+	}, time.Second, 10*time.Millisecond)
+
+	stored := repository.get(position.UUID)
+	assert.Equal(t, 6.0, stored.FilledQuantity)
+	assert.Equal(t, 19.0, stored.AveragePrice)
+	assert.InDelta(t, 0.15, stored.Fee, 1e-9)
+	assert.Equal(t, types.OrderStatusFilled, repository.get(first.UUID).Status)
+	assert.Equal(t, types.OrderStatusPartiallyFilled, repository.get(second.UUID).Status)
+
+	// the rest of second leg is cancelled on exchange
+	exchangeClient.set("2", &commonTypes.Order{Status: commonTypes.OrderStatusPartiallyCanceled, ExecutedQuantity: 3, AveragePrice: 17, Fee: 0.075})
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusFilled
+	}, time.Second, 10*time.Millisecond)
+
+	stored = repository.get(position.UUID)
+	assert.Equal(t, 7.0, stored.FilledQuantity)
+	assert.Equal(t, types.OrderStatusCancelled, repository.get(second.UUID).Status)
+}
+
+func TestReconcilerEndsUnfilledPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		legStatus types.OrderStatus
+		// executed is quantity filled before cancel reached exchange
+		executed float64
+		status   types.OrderStatus
+	}{
+		{"missed", types.OrderStatusMissed, 0, types.OrderStatusMissed},
+		{"expired", types.OrderStatusExpired, 0, types.OrderStatusExpired},
+		{"cancelled", types.OrderStatusCancelled, 0, types.OrderStatusCancelled},
+		{"filled before expiry", types.OrderStatusExpired, 4, types.OrderStatusFilled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			position := types.Order{UUID: uuid.New(), Exchange: commonTypes.ExchangeBybit, Symbol: "ETC", Quantity: 10, Status: types.OrderStatusPlaced}
+			leg := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Exchange: position.Exchange, ExchangeOrderID: "1", Quantity: 10, Status: test.legStatus}
+
+			repository := newMemoryRepository(position, leg)
+			exchangeClient := &reconcilerClient{orders: map[commonTypes.OrderID]*commonTypes.Order{
+				"1": {Status: commonTypes.OrderStatusCanceled, ExecutedQuantity: test.executed, AveragePrice: 20},
+			}}
+
+			reconciler := newTestReconciler(repository, exchangeClient)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = reconciler.Start(ctx)
+			}()
+
+			require.Eventually(t, func() bool {
+				return repository.get(position.UUID).Status == test.status
+			}, time.Second, 10*time.Millisecond)
+			assert.Equal(t, test.executed, repository.get(position.UUID).FilledQuantity)
+			assert.Equal(t, test.legStatus, repository.get(leg.UUID).Status)
+		})
+	}
+}
+
+func TestReconcilerFillsPositionWithExpiredLeg(t *testing.T) {
+	position := types.Order{UUID: uuid.New(), Exchange: commonTypes.ExchangeBybit, Symbol: "ETC", Quantity: 10, Status: types.OrderStatusPartiallyFilled}
+	filled := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Exchange: position.Exchange, ExchangeOrderID: "1", Leg: 0, Quantity: 5, FilledQuantity: 5, AveragePrice: 20, Status: types.OrderStatusFilled}
+	expired := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Exchange: position.Exchange, ExchangeOrderID: "2", Leg: 1, Quantity: 5, Status: types.OrderStatusExpired}
+
+	repository := newMemoryRepository(position, filled, expired)
+	exchangeClient := &reconcilerClient{orders: map[commonTypes.OrderID]*commonTypes.Order{
+		"1": {Status: commonTypes.OrderStatusFilled, ExecutedQuantity: 5, AveragePrice: 20},
+		"2": {Status: commonTypes.OrderStatusCanceled},
+	}}
+
+	reconciler := newTestReconciler(repository, exchangeClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = reconciler.Start(ctx)
+	}()
+
+	// position holds what first leg filled, it is not expired with the second one
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusFilled
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 5.0, repository.get(position.UUID).FilledQuantity)
+	assert.Equal(t, types.OrderStatusExpired, repository.get(expired.UUID).Status)
+}
+
+func TestReconcilerResolvesPendingLegs(t *testing.T) {
+	position := types.Order{UUID: uuid.New(), Exchange: commonTypes.ExchangeBybit, Symbol: "ETC", Quantity: 10, Status: types.OrderStatusPlaced}
+	placed := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Leg: 0, Exchange: position.Exchange, ClientOrderID: "leg-0", ExchangeOrderID: "1", Quantity: 4, Status: types.OrderStatusPlaced}
+	// send of these legs failed without telling whether it reached exchange
+	sent := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Leg: 1, Exchange: position.Exchange, ClientOrderID: "leg-1", Quantity: 3, Status: types.OrderStatusPending}
+	lost := types.Order{UUID: uuid.New(), ParentUUID: position.UUID, Leg: 2, Exchange: position.Exchange, ClientOrderID: "leg-2", Quantity: 3, Status: types.OrderStatusPending}
+
+	repository := newMemoryRepository(position, placed, sent, lost)
+	exchangeClient := &reconcilerClient{orders: map[commonTypes.OrderID]*commonTypes.Order{
+		"1": {ClientOrderID: "leg-0", Status: commonTypes.OrderStatusFilled, ExecutedQuantity: 4, AveragePrice: 20},
+		"2": {ClientOrderID: "leg-1", Status: commonTypes.OrderStatusFilled, ExecutedQuantity: 3, AveragePrice: 20},
+	}}
+
+	reconciler := newTestReconciler(repository, exchangeClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = reconciler.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return repository.get(position.UUID).Status == types.OrderStatusFilled
+	}, time.Second, 10*time.Millisecond)
+
+	stored := repository.get(sent.UUID)
+	assert.Equal(t, types.OrderStatusFilled, stored.Status)
+	assert.Equal(t, commonTypes.OrderID("2"), stored.ExchangeOrderID)
+	assert.Equal(t, types.OrderStatusRejected, repository.get(lost.UUID).Status)
+	assert.Equal(t, 7.0, repository.get(position.UUID).FilledQuantity)
+}
@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type gormOrderEntity struct {
+	UUID            uuid.UUID `gorm:"primaryKey"`
+	ParentUUID      uuid.UUID `gorm:"index"`
+	Leg             int
+	SignalUUID      uuid.UUID `gorm:"index"`
+	Channel         commonTypes.SignalChannel
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Exchange        commonTypes.Exchange
+	ExchangeOrderID commonTypes.OrderID
+	ClientOrderID   string `gorm:"index"`
+	Symbol          string
+	BaseSymbol      string
+	Position        commonTypes.Position
+	Type            commonTypes.OrderType
+	Leverage        float64
+	Entry           float64
+	Quantity        float64
+	FilledQuantity  float64
+	AveragePrice    float64
+	Fee             float64
+	QuantityFee     float64
+	Targets         []types.Target `gorm:"serializer:json"`
+	Stop            float64
+	TargetsHit      int
+	ClosedQuantity  float64
+	BestPrice       float64
+	StopMoved       bool
+	RealisedPnl     float64
+	Status          types.OrderStatus `gorm:"index"`
+}
+
+// gormOrderStatusEntity is an append only record of order status change
+type gormOrderStatusEntity struct {
+	ID              uint      `gorm:"primaryKey"`
+	OrderUUID       uuid.UUID `gorm:"index"`
+	From            types.OrderStatus
+	To              types.OrderStatus
+	ExchangeOrderID commonTypes.OrderID
+	CreatedAt       time.Time
+}
+
+func newEntityFromOrder(order *types.Order) *gormOrderEntity {
+	return &gormOrderEntity{
+		UUID:            order.UUID,
+		ParentUUID:      order.ParentUUID,
+		Leg:             order.Leg,
+		SignalUUID:      order.SignalUUID,
+		Channel:         order.Channel,
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+		Exchange:        order.Exchange,
+		ExchangeOrderID: order.ExchangeOrderID,
+		ClientOrderID:   order.ClientOrderID,
+		Symbol:          order.Symbol,
+		BaseSymbol:      order.BaseSymbol,
+		Position:        order.Position,
+		Type:            order.Type,
+		Leverage:        order.Leverage,
+		Entry:           order.Entry,
+		Quantity:        order.Quantity,
+		FilledQuantity:  order.FilledQuantity,
+		AveragePrice:    order.AveragePrice,
+		Fee:             order.Fee,
+		QuantityFee:     order.QuantityFee,
+		Targets:         order.Targets,
+		Stop:            order.Stop,
+		TargetsHit:      order.TargetsHit,
+		ClosedQuantity:  order.ClosedQuantity,
+		BestPrice:       order.BestPrice,
+		StopMoved:       order.StopMoved,
+		RealisedPnl:     order.RealisedPnl,
+		Status:          order.Status,
+	}
+}
+
+func (e *gormOrderEntity) toOrder() *types.Order {
+	return &types.Order{
+		UUID:            e.UUID,
+		ParentUUID:      e.ParentUUID,
+		Leg:             e.Leg,
+		SignalUUID:      e.SignalUUID,
+		Channel:         e.Channel,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+		Exchange:        e.Exchange,
+		ExchangeOrderID: e.ExchangeOrderID,
+		ClientOrderID:   e.ClientOrderID,
+		Symbol:          e.Symbol,
+		BaseSymbol:      e.BaseSymbol,
+		Position:        e.Position,
+		Type:            e.Type,
+		Leverage:        e.Leverage,
+		Entry:           e.Entry,
+		Quantity:        e.Quantity,
+		FilledQuantity:  e.FilledQuantity,
+		AveragePrice:    e.AveragePrice,
+		Fee:             e.Fee,
+		QuantityFee:     e.QuantityFee,
+		Targets:         e.Targets,
+		Stop:            e.Stop,
+		TargetsHit:      e.TargetsHit,
+		ClosedQuantity:  e.ClosedQuantity,
+		BestPrice:       e.BestPrice,
+		StopMoved:       e.StopMoved,
+		RealisedPnl:     e.RealisedPnl,
+		Status:          e.Status,
+	}
+}
+
+type GormOrder struct {
+	db *gorm.DB
+}
+
+func NewGormOrder(
+	db *gorm.DB,
+) (*GormOrder, error) {
+	if err := db.AutoMigrate(&gormOrderEntity{}, &gormOrderStatusEntity{}); err != nil {
+		return nil, fmt.Errorf("NewGormOrder : %w", err)
+	}
+
+	return &GormOrder{
+		db: db,
+	}, nil
+}
+
+// Create saves order together with its initial status in history
+func (g *GormOrder) Create(ctx context.Context, order *types.Order) error {
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(newEntityFromOrder(order)).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&gormOrderStatusEntity{
+			OrderUUID:       order.UUID,
+			To:              order.Status,
+			ExchangeOrderID: order.ExchangeOrderID,
+			CreatedAt:       order.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("GormOrder::Create : %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatus moves order to status and appends the change to history.
+// Change is applied only if order still has the status it was read with,
+// otherwise types.ErrOrderStatusConflict is returned.
+func (g *GormOrder) UpdateStatus(ctx context.Context, order *types.Order, status types.OrderStatus) error {
+	now := time.Now()
+
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&gormOrderEntity{}).
+			Where("uuid = ? AND status = ?", order.UUID, order.Status).
+			Updates(map[string]any{
+				"status":            status,
+				"exchange_order_id": order.ExchangeOrderID,
+				"updated_at":        now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return types.ErrOrderStatusConflict
+		}
+
+		return tx.Create(&gormOrderStatusEntity{
+			OrderUUID:       order.UUID,
+			From:            order.Status,
+			To:              status,
+			ExchangeOrderID: order.ExchangeOrderID,
+			CreatedAt:       now,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("GormOrder::UpdateStatus : %w", err)
+	}
+
+	order.Status = status
+	order.UpdatedAt = now
+
+	return nil
+}
+
+// Update saves how position is managed, status is changed only by UpdateStatus and targets by TakeTarget
+func (g *GormOrder) Update(ctx context.Context, order *types.Order) error {
+	now := time.Now()
+
+	err := g.db.WithContext(ctx).
+		Model(&gormOrderEntity{}).
+		Where("uuid = ?", order.UUID).
+		Updates(map[string]any{
+			"stop":            order.Stop,
+			"stop_moved":      order.StopMoved,
+			"best_price":      order.BestPrice,
+			"closed_quantity": order.ClosedQuantity,
+			"realised_pnl":    order.RealisedPnl,
+			"updated_at":      now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("GormOrder::Update : %w", err)
+	}
+
+	order.UpdatedAt = now
+
+	return nil
+}
+
+// UpdateFill saves how much of order is filled on exchange
+func (g *GormOrder) UpdateFill(ctx context.Context, order *types.Order) error {
+	now := time.Now()
+
+	err := g.db.WithContext(ctx).
+		Model(&gormOrderEntity{}).
+		Where("uuid = ?", order.UUID).
+		Updates(map[string]any{
+			"filled_quantity": order.FilledQuantity,
+			"average_price":   order.AveragePrice,
+			"fee":             order.Fee,
+			"quantity_fee":    order.QuantityFee,
+			"updated_at":      now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("GormOrder::UpdateFill : %w", err)
+	}
+
+	order.UpdatedAt = now
+
+	return nil
+}
+
+// TakeTarget claims next target of position and adds quantity to closed part.
+// Claim succeeds only if neither status nor taken targets changed since order was read,
+// otherwise types.ErrOrderStatusConflict is returned.
+func (g *GormOrder) TakeTarget(ctx context.Context, order *types.Order, quantity float64) error {
+	now := time.Now()
+
+	result := g.db.WithContext(ctx).
+		Model(&gormOrderEntity{}).
+		Where("uuid = ? AND status = ? AND targets_hit = ?", order.UUID, order.Status, order.TargetsHit).
+		Updates(map[string]any{
+			"targets_hit":     gorm.Expr("targets_hit + 1"),
+			"closed_quantity": gorm.Expr("closed_quantity + ?", quantity),
+			"updated_at":      now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("GormOrder::TakeTarget : %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("GormOrder::TakeTarget : %w", types.ErrOrderStatusConflict)
+	}
+
+	order.TargetsHit++
+	order.ClosedQuantity += quantity
+	order.UpdatedAt = now
+
+	return nil
+}
+
+// ReleaseTarget gives back target claimed by TakeTarget when exit order of its slice wasn't sent.
+// Release fails with types.ErrOrderStatusConflict when another target was taken since the claim.
+func (g *GormOrder) ReleaseTarget(ctx context.Context, order *types.Order, quantity float64) error {
+	now := time.Now()
+
+	result := g.db.WithContext(ctx).
+		Model(&gormOrderEntity{}).
+		Where("uuid = ? AND targets_hit = ?", order.UUID, order.TargetsHit).
+		Updates(map[string]any{
+			"targets_hit":     gorm.Expr("targets_hit - 1"),
+			"closed_quantity": gorm.Expr("closed_quantity - ?", quantity),
+			"updated_at":      now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("GormOrder::ReleaseTarget : %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("GormOrder::ReleaseTarget : %w", types.ErrOrderStatusConflict)
+	}
+
+	order.TargetsHit--
+	order.ClosedQuantity -= quantity
+	order.UpdatedAt = now
+
+	return nil
+}
+
+func (g *GormOrder) Get(ctx context.Context, orderUUID uuid.UUID) (*types.Order, error) {
+	var entity gormOrderEntity
+	err := g.db.WithContext(ctx).Where("uuid = ?", orderUUID).First(&entity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, types.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GormOrder::Get : %w", err)
+	}
+
+	return entity.toOrder(), nil
+}
+
+func (g *GormOrder) FindBySignal(ctx context.Context, signalUUID uuid.UUID) ([]*types.Order, error) {
+	var entities []gormOrderEntity
+	if err := g.db.WithContext(ctx).
+		Where("signal_uuid = ?", signalUUID).
+		Order("created_at").
+		Find(&entities).Error; err != nil {
+		return nil, fmt.Errorf("GormOrder::FindBySignal : %w", err)
+	}
+
+	return toOrders(entities), nil
+}
+
+// FindPositions returns positions, i.e. orders without parent, being in one of statuses
+func (g *GormOrder) FindPositions(ctx context.Context, statuses ...types.OrderStatus) ([]*types.Order, error) {
+	var entities []gormOrderEntity
+	if err := g.db.WithContext(ctx).
+		Where("parent_uuid = ? AND status IN ?", uuid.Nil, statuses).
+		Order("created_at").
+		Find(&entities).Error; err != nil {
+		return nil, fmt.Errorf("GormOrder::FindPositions : %w", err)
+	}
+
+	return toOrders(entities), nil
+}
+
+// FindClosedSince returns positions closed at since or later
+func (g *GormOrder) FindClosedSince(ctx context.Context, since time.Time) ([]*types.Order, error) {
+	var entities []gormOrderEntity
+	if err := g.db.WithContext(ctx).
+		Where("parent_uuid = ? AND status = ? AND updated_at >= ?", uuid.Nil, types.OrderStatusClosed, since).
+		Order("updated_at").
+		Find(&entities).Error; err != nil {
+		return nil, fmt.Errorf("GormOrder::FindClosedSince : %w", err)
+	}
+
+	return toOrders(entities), nil
+}
+
+// FindLegs returns orders position is entered with ordered by leg
+func (g *GormOrder) FindLegs(ctx context.Context, parentUUID uuid.UUID) ([]*types.Order, error) {
+	var entities []gormOrderEntity
+	if err := g.db.WithContext(ctx).
+		Where("parent_uuid = ?", parentUUID).
+		Order("leg").
+		Find(&entities).Error; err != nil {
+		return nil, fmt.Errorf("GormOrder::FindLegs : %w", err)
+	}
+
+	return toOrders(entities), nil
+}
+
+func toOrders(entities []gormOrderEntity) []*types.Order {
+	orders := make([]*types.Order, 0, len(entities))
+	for i := range entities {
+		orders = append(orders, entities[i].toOrder())
+	}
+
+	return orders
+}
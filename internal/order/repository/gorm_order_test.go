@@ -0,0 +1,173 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"trade_bot/internal/order/repository"
+	"trade_bot/internal/order/types"
+)
+
+type statusRow struct {
+	OrderUUID       uuid.UUID
+	From            types.OrderStatus
+	To              types.OrderStatus
+	ExchangeOrderID string
+}
+
+func newTestGormOrder(t *testing.T) (*repository.GormOrder, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "orders.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	orders, err := repository.NewGormOrder(db)
+	require.NoError(t, err)
+
+	return orders, db
+}
+
+func newTestPosition(signalUUID uuid.UUID) *types.Order {
+	return &types.Order{
+		UUID:       uuid.New(),
+		SignalUUID: signalUUID,
+		CreatedAt:  time.Now(),
+		Symbol:     "ETC/USDT",
+		Quantity:   5,
+		Targets:    []types.Target{{Price: 25, Percent: 100}},
+		Stop:       18,
+		Status:     types.OrderStatusNew,
+	}
+}
+
+func history(t *testing.T, db *gorm.DB, orderUUID uuid.UUID) []statusRow {
+	t.Helper()
+
+	var rows []statusRow
+	require.NoError(t, db.Table("gorm_order_status_entities").
+		Where("order_uuid = ?", orderUUID).
+		Order("id").
+		Find(&rows).Error)
+
+	return rows
+}
+
+func TestGormOrderCreate(t *testing.T) {
+	orders, db := newTestGormOrder(t)
+	position := newTestPosition(uuid.New())
+
+	require.NoError(t, orders.Create(context.Background(), position))
+
+	stored, err := orders.Get(context.Background(), position.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, position.Symbol, stored.Symbol)
+	assert.Equal(t, position.Targets, stored.Targets)
+	assert.Equal(t, uuid.Nil, stored.ParentUUID)
+	assert.Equal(t, types.OrderStatusNew, stored.Status)
+
+	assert.Equal(t, []statusRow{{OrderUUID: position.UUID, To: types.OrderStatusNew}}, history(t, db, position.UUID))
+
+	_, err = orders.Get(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, types.ErrOrderNotFound)
+}
+
+func TestGormOrderUpdateStatus(t *testing.T) {
+	orders, db := newTestGormOrder(t)
+	position := newTestPosition(uuid.New())
+	require.NoError(t, orders.Create(context.Background(), position))
+
+	stale := *position
+	position.ExchangeOrderID = "exchange-1"
+	require.NoError(t, orders.UpdateStatus(context.Background(), position, types.OrderStatusPlaced))
+	assert.Equal(t, types.OrderStatusPlaced, position.Status)
+
+	err := orders.UpdateStatus(context.Background(), &stale, types.OrderStatusRejected)
+	assert.ErrorIs(t, err, types.ErrOrderStatusConflict)
+
+	stored, err := orders.Get(context.Background(), position.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, types.OrderStatusPlaced, stored.Status)
+	assert.Equal(t, position.ExchangeOrderID, stored.ExchangeOrderID)
+
+	assert.Equal(t, []statusRow{
+		{OrderUUID: position.UUID, To: types.OrderStatusNew},
+		{OrderUUID: position.UUID, From: types.OrderStatusNew, To: types.OrderStatusPlaced, ExchangeOrderID: "exchange-1"},
+	}, history(t, db, position.UUID))
+}
+
+func TestGormOrderFind(t *testing.T) {
+	orders, _ := newTestGormOrder(t)
+	signalUUID := uuid.New()
+
+	position := newTestPosition(signalUUID)
+	position.Status = types.OrderStatusPending
+	require.NoError(t, orders.Create(context.Background(), position))
+
+	for _, leg := range []int{1, 0} {
+		require.NoError(t, orders.Create(context.Background(), &types.Order{
+			UUID:       uuid.New(),
+			ParentUUID: position.UUID,
+			Leg:        leg,
+			SignalUUID: signalUUID,
+			CreatedAt:  time.Now(),
+			Status:     types.OrderStatusPlaced,
+		}))
+	}
+
+	closed := newTestPosition(uuid.New())
+	closed.Status = types.OrderStatusClosed
+	require.NoError(t, orders.Create(context.Background(), closed))
+
+	bySignal, err := orders.FindBySignal(context.Background(), signalUUID)
+	require.NoError(t, err)
+	assert.Len(t, bySignal, 3)
+
+	positions, err := orders.FindPositions(context.Background(), types.OrderStatusPending, types.OrderStatusFilled)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, position.UUID, positions[0].UUID)
+
+	legs, err := orders.FindLegs(context.Background(), position.UUID)
+	require.NoError(t, err)
+	require.Len(t, legs, 2)
+	assert.Equal(t, 0, legs[0].Leg)
+	assert.Equal(t, 1, legs[1].Leg)
+	assert.Equal(t, position.UUID, legs[0].ParentUUID)
+}
+
+func TestGormOrderTakeTarget(t *testing.T) {
+	orders, _ := newTestGormOrder(t)
+	position := newTestPosition(uuid.New())
+	position.Status = types.OrderStatusFilled
+	require.NoError(t, orders.Create(context.Background(), position))
+
+	stale := *position
+	require.NoError(t, orders.TakeTarget(context.Background(), position, 2))
+	assert.Equal(t, 1, position.TargetsHit)
+	assert.Equal(t, 2.0, position.ClosedQuantity)
+
+	err := orders.TakeTarget(context.Background(), &stale, 2)
+	assert.ErrorIs(t, err, types.ErrOrderStatusConflict)
+
+	stored, err := orders.Get(context.Background(), position.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.TargetsHit)
+	assert.Equal(t, 2.0, stored.ClosedQuantity)
+
+	err = orders.ReleaseTarget(context.Background(), &stale, 2)
+	assert.ErrorIs(t, err, types.ErrOrderStatusConflict)
+
+	require.NoError(t, orders.ReleaseTarget(context.Background(), position, 2))
+	stored, err = orders.Get(context.Background(), position.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.TargetsHit)
+	assert.Equal(t, 0.0, stored.ClosedQuantity)
+}
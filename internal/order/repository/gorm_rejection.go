@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type gormRejectionEntity struct {
+	ID         uint      `gorm:"primaryKey"`
+	OrderUUID  uuid.UUID `gorm:"index"`
+	SignalUUID uuid.UUID `gorm:"index"`
+	Channel    commonTypes.SignalChannel
+	Exchange   commonTypes.Exchange
+	Symbol     string
+	BaseSymbol string
+	Reason     types.RejectionReason `gorm:"index"`
+	Value      float64
+	Limit      float64
+	CreatedAt  time.Time
+}
+
+func newEntityFromRejection(rejection *types.Rejection) *gormRejectionEntity {
+	return &gormRejectionEntity{
+		OrderUUID:  rejection.OrderUUID,
+		SignalUUID: rejection.SignalUUID,
+		Channel:    rejection.Channel,
+		Exchange:   rejection.Exchange,
+		Symbol:     rejection.Symbol,
+		BaseSymbol: rejection.BaseSymbol,
+		Reason:     rejection.Reason,
+		Value:      rejection.Value,
+		Limit:      rejection.Limit,
+		CreatedAt:  rejection.CreatedAt,
+	}
+}
+
+// GormRejection keeps positions risk manager didn't let to exchange
+type GormRejection struct {
+	db *gorm.DB
+}
+
+func NewGormRejection(
+	db *gorm.DB,
+) (*GormRejection, error) {
+	if err := db.AutoMigrate(&gormRejectionEntity{}); err != nil {
+		return nil, fmt.Errorf("NewGormRejection : %w", err)
+	}
+
+	return &GormRejection{
+		db: db,
+	}, nil
+}
+
+func (g *GormRejection) Create(ctx context.Context, rejection *types.Rejection) error {
+	if err := g.db.WithContext(ctx).Create(newEntityFromRejection(rejection)).Error; err != nil {
+		return fmt.Errorf("GormRejection::Create : %w", err)
+	}
+
+	return nil
+}
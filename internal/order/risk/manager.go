@@ -0,0 +1,196 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"trade_bot/internal/order/types"
+)
+
+// openStatuses are statuses of positions holding exposure on exchange
+var openStatuses = []types.OrderStatus{
+	types.OrderStatusPending,
+	types.OrderStatusPlaced,
+	types.OrderStatusPartiallyFilled,
+	types.OrderStatusFilled,
+	types.OrderStatusClosing,
+}
+
+type orderRepository interface {
+	FindPositions(ctx context.Context, statuses ...types.OrderStatus) ([]*types.Order, error)
+	FindClosedSince(ctx context.Context, since time.Time) ([]*types.Order, error)
+}
+
+type rejectionRepository interface {
+	Create(ctx context.Context, rejection *types.Rejection) error
+}
+
+// Limits bound what positions may be opened, zero limit is not checked.
+// Exposure and loss are in base symbol (e.g. USDT).
+type Limits struct {
+	MaxOpenPositions   int
+	MaxSymbolExposure  float64
+	MaxChannelExposure float64
+	MaxLeverage        float64
+	// MaxDailyLoss stops trading until the next UTC day once positions closed today lost that much
+	MaxDailyLoss float64
+}
+
+type ManagerOptions struct {
+	Limits              Limits
+	OrderRepository     orderRepository
+	RejectionRepository rejectionRepository
+	Logger              *logrus.Logger
+}
+
+// Manager is a gate every position passes before it is sent to exchange
+type Manager struct {
+	limits              Limits
+	orderRepository     orderRepository
+	rejectionRepository rejectionRepository
+	log                 *logrus.Logger
+	now                 func() time.Time
+}
+
+func NewManager(opt *ManagerOptions) *Manager {
+	return &Manager{
+		limits:              opt.Limits,
+		orderRepository:     opt.OrderRepository,
+		rejectionRepository: opt.RejectionRepository,
+		log:                 opt.Logger,
+		now:                 time.Now,
+	}
+}
+
+// CapLeverage returns leverage not higher than allowed, whatever signal suggests
+func (m *Manager) CapLeverage(leverage float64) float64 {
+	if m.limits.MaxLeverage <= 0 || leverage <= m.limits.MaxLeverage {
+		return leverage
+	}
+
+	m.log.
+		WithFields(logrus.Fields{
+			"Leverage":    leverage,
+			"MaxLeverage": m.limits.MaxLeverage,
+		}).
+		Info("Leverage capped by risk limits")
+
+	return m.limits.MaxLeverage
+}
+
+// Check lets position through when it keeps every limit. Otherwise rejection is stored
+// and *types.RiskError with the broken limit is returned.
+func (m *Manager) Check(ctx context.Context, position *types.Order) error {
+	riskErr, err := m.evaluate(ctx, position)
+	if err != nil {
+		return fmt.Errorf("Manager::Check : %w", err)
+	}
+	if riskErr == nil {
+		return nil
+	}
+
+	m.log.
+		WithFields(logrus.Fields{
+			"OrderUUID":  position.UUID,
+			"SignalUUID": position.SignalUUID,
+			"Channel":    position.Channel,
+			"Symbol":     position.Symbol,
+			"Reason":     riskErr.Reason,
+			"Value":      riskErr.Value,
+			"Limit":      riskErr.Limit,
+		}).
+		Warn("Position rejected by risk limits")
+
+	if err := m.rejectionRepository.Create(ctx, &types.Rejection{
+		OrderUUID:  position.UUID,
+		SignalUUID: position.SignalUUID,
+		Channel:    position.Channel,
+		Exchange:   position.Exchange,
+		Symbol:     position.Symbol,
+		BaseSymbol: position.BaseSymbol,
+		Reason:     riskErr.Reason,
+		Value:      riskErr.Value,
+		Limit:      riskErr.Limit,
+		CreatedAt:  m.now(),
+	}); err != nil {
+		return fmt.Errorf("Manager::Check : %w", err)
+	}
+
+	return riskErr
+}
+
+func (m *Manager) evaluate(ctx context.Context, position *types.Order) (*types.RiskError, error) {
+	if m.limits.MaxDailyLoss > 0 {
+		loss, err := m.dailyLoss(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if loss >= m.limits.MaxDailyLoss {
+			return &types.RiskError{
+				Reason: types.RejectionReasonDailyLoss,
+				Value:  loss,
+				Limit:  m.limits.MaxDailyLoss,
+			}, nil
+		}
+	}
+
+	open, err := m.orderRepository.FindPositions(ctx, openStatuses...)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.limits.MaxOpenPositions > 0 && len(open)+1 > m.limits.MaxOpenPositions {
+		return &types.RiskError{
+			Reason: types.RejectionReasonOpenPositions,
+			Value:  float64(len(open) + 1),
+			Limit:  float64(m.limits.MaxOpenPositions),
+		}, nil
+	}
+
+	symbolExposure, channelExposure := position.Exposure(), position.Exposure()
+	for _, other := range open {
+		if other.Symbol == position.Symbol && other.BaseSymbol == position.BaseSymbol {
+			symbolExposure += other.Exposure()
+		}
+		if other.Channel == position.Channel {
+			channelExposure += other.Exposure()
+		}
+	}
+
+	if m.limits.MaxSymbolExposure > 0 && symbolExposure > m.limits.MaxSymbolExposure {
+		return &types.RiskError{
+			Reason: types.RejectionReasonSymbolExposure,
+			Value:  symbolExposure,
+			Limit:  m.limits.MaxSymbolExposure,
+		}, nil
+	}
+	if m.limits.MaxChannelExposure > 0 && channelExposure > m.limits.MaxChannelExposure {
+		return &types.RiskError{
+			Reason: types.RejectionReasonChannelExposure,
+			Value:  channelExposure,
+			Limit:  m.limits.MaxChannelExposure,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// dailyLoss returns loss of positions closed since the start of UTC day, profit makes up for loss
+func (m *Manager) dailyLoss(ctx context.Context) (float64, error) {
+	dayStart := m.now().UTC().Truncate(24 * time.Hour)
+
+	closed, err := m.orderRepository.FindClosedSince(ctx, dayStart)
+	if err != nil {
+		return 0, err
+	}
+
+	pnl := 0.0
+	for _, position := range closed {
+		pnl += position.RealisedPnl
+	}
+
+	return -pnl, nil
+}
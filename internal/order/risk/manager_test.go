@@ -0,0 +1,157 @@
+package risk_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/order/risk"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type riskRepository struct {
+	open   []*types.Order
+	closed []*types.Order
+}
+
+func (r *riskRepository) FindPositions(_ context.Context, _ ...types.OrderStatus) ([]*types.Order, error) {
+	return r.open, nil
+}
+
+func (r *riskRepository) FindClosedSince(_ context.Context, _ time.Time) ([]*types.Order, error) {
+	return r.closed, nil
+}
+
+type rejectionRepository struct {
+	rejections []*types.Rejection
+}
+
+func (r *rejectionRepository) Create(_ context.Context, rejection *types.Rejection) error {
+	r.rejections = append(r.rejections, rejection)
+
+	return nil
+}
+
+func newPosition(symbol string, channel commonTypes.SignalChannel, entry, quantity float64) *types.Order {
+	return &types.Order{
+		UUID:       uuid.New(),
+		SignalUUID: uuid.New(),
+		Channel:    channel,
+		Exchange:   commonTypes.ExchangeBybit,
+		Symbol:     symbol,
+		BaseSymbol: "USDT",
+		Position:   commonTypes.PositionLong,
+		Entry:      entry,
+		Quantity:   quantity,
+		Status:     types.OrderStatusNew,
+	}
+}
+
+func TestManagerCheck(t *testing.T) {
+	const otherChannel commonTypes.SignalChannel = "other"
+
+	tests := []struct {
+		name     string
+		limits   risk.Limits
+		open     []*types.Order
+		closed   []*types.Order
+		position *types.Order
+		reason   types.RejectionReason
+	}{
+		{
+			name:     "within limits",
+			limits:   risk.Limits{MaxOpenPositions: 2, MaxSymbolExposure: 300, MaxChannelExposure: 500, MaxDailyLoss: 50},
+			open:     []*types.Order{newPosition("BTC", commonTypes.SignalChannelHardcoreVIP, 100, 2)},
+			closed:   []*types.Order{{RealisedPnl: -30}},
+			position: newPosition("ETC", commonTypes.SignalChannelHardcoreVIP, 20, 10),
+		},
+		{
+			name:     "open positions",
+			limits:   risk.Limits{MaxOpenPositions: 1},
+			open:     []*types.Order{newPosition("BTC", commonTypes.SignalChannelHardcoreVIP, 100, 2)},
+			position: newPosition("ETC", commonTypes.SignalChannelHardcoreVIP, 20, 10),
+			reason:   types.RejectionReasonOpenPositions,
+		},
+		{
+			name:     "symbol exposure",
+			limits:   risk.Limits{MaxSymbolExposure: 300},
+			open:     []*types.Order{newPosition("ETC", otherChannel, 20, 10)},
+			position: newPosition("ETC", commonTypes.SignalChannelHardcoreVIP, 20, 10),
+			reason:   types.RejectionReasonSymbolExposure,
+		},
+		{
+			name:   "closed part is not exposed",
+			limits: risk.Limits{MaxSymbolExposure: 300},
+			open: []*types.Order{func() *types.Order {
+				position := newPosition("ETC", otherChannel, 20, 10)
+				position.ClosedQuantity = 6
+
+				return position
+			}()},
+			position: newPosition("ETC", commonTypes.SignalChannelHardcoreVIP, 20, 10),
+		},
+		{
+			name:     "channel exposure",
+			limits:   risk.Limits{MaxChannelExposure: 300},
+			open:     []*types.Order{newPosition("BTC", commonTypes.SignalChannelHardcoreVIP, 100, 2)},
+			position: newPosition("ETC", commonTypes.SignalChannelHardcoreVIP, 20, 10),
+			reason:   types.RejectionReasonChannelExposure,
+		},
+		{
+			name:     "daily loss",
+			limits:   risk.Limits{MaxDailyLoss: 50},
+			closed:   []*types.Order{{RealisedPnl: -70}, {RealisedPnl: 15}},
+			position: newPosition("ETC", commonTypes.SignalChannelHardcoreVIP, 20, 10),
+			reason:   types.RejectionReasonDailyLoss,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rejections := &rejectionRepository{}
+			manager := risk.NewManager(&risk.ManagerOptions{
+				Limits:              test.limits,
+				OrderRepository:     &riskRepository{open: test.open, closed: test.closed},
+				RejectionRepository: rejections,
+				Logger:              logrus.New(),
+			})
+
+			err := manager.Check(context.Background(), test.position)
+			if test.reason == "" {
+				require.NoError(t, err)
+				assert.Empty(t, rejections.rejections)
+
+				return
+			}
+
+			var riskErr *types.RiskError
+			require.ErrorAs(t, err, &riskErr)
+			assert.ErrorIs(t, err, types.ErrOrderRiskRejected)
+			assert.Equal(t, test.reason, riskErr.Reason)
+
+			require.Len(t, rejections.rejections, 1)
+			assert.Equal(t, test.position.UUID, rejections.rejections[0].OrderUUID)
+			assert.Equal(t, test.position.SignalUUID, rejections.rejections[0].SignalUUID)
+			assert.Equal(t, test.reason, rejections.rejections[0].Reason)
+		})
+	}
+}
+
+func TestManagerCapLeverage(t *testing.T) {
+	manager := risk.NewManager(&risk.ManagerOptions{
+		Limits: risk.Limits{MaxLeverage: 10},
+		Logger: logrus.New(),
+	})
+
+	assert.Equal(t, 10.0, manager.CapLeverage(50))
+	assert.Equal(t, 5.0, manager.CapLeverage(5))
+
+	unlimited := risk.NewManager(&risk.ManagerOptions{Logger: logrus.New()})
+	assert.Equal(t, 50.0, unlimited.CapLeverage(50))
+}
@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+// symbolInfoTTL is how long symbol filters are cached, exchanges change them rarely
+const symbolInfoTTL = time.Hour
+
+type cachedSymbolInfo struct {
+	info      *clientTypes.SymbolInfo
+	fetchedAt time.Time
+}
+
+// Normalizer fits orders to symbol filters of exchange: price is rounded to tick size,
+// quantity is rounded down to step size and then checked against min quantity and min notional.
+type Normalizer struct {
+	mu      sync.Mutex
+	symbols map[string]*cachedSymbolInfo
+	now     func() time.Time
+}
+
+func NewNormalizer() *Normalizer {
+	return &Normalizer{
+		symbols: make(map[string]*cachedSymbolInfo),
+		now:     time.Now,
+	}
+}
+
+// Normalize returns price and quantity accepted by exchange. Price of zero, as of market order,
+// is left as is and reference price is used to check notional instead.
+// *types.MinimumError is returned when quantity doesn't meet symbol minimums.
+func (n *Normalizer) Normalize(
+	ctx context.Context,
+	exchangeClient client.Client,
+	symbol, baseSymbol string,
+	price, quantity, referencePrice float64,
+) (float64, float64, error) {
+	info, err := n.symbolInfo(ctx, exchangeClient, symbol, baseSymbol)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Normalizer::Normalize : %w", err)
+	}
+
+	if price > 0 {
+		price = roundToStep(price, info.TickSize, false)
+		referencePrice = price
+	}
+	quantity = roundToStep(quantity, info.StepSize, true)
+
+	notional := quantity * referencePrice
+	if quantity <= 0 || quantity < info.MinQuantity || (referencePrice > 0 && notional < info.MinNotional) {
+		return 0, 0, &types.MinimumError{
+			Symbol:      symbol + baseSymbol,
+			Quantity:    quantity,
+			MinQuantity: info.MinQuantity,
+			Notional:    notional,
+			MinNotional: info.MinNotional,
+		}
+	}
+
+	return price, quantity, nil
+}
+
+func (n *Normalizer) symbolInfo(ctx context.Context, exchangeClient client.Client, symbol, baseSymbol string) (*clientTypes.SymbolInfo, error) {
+	key := symbolKey(exchangeClient.Name(), symbol, baseSymbol)
+
+	n.mu.Lock()
+	cached, ok := n.symbols[key]
+	n.mu.Unlock()
+	if ok && n.now().Sub(cached.fetchedAt) < symbolInfoTTL {
+		return cached.info, nil
+	}
+
+	info, err := exchangeClient.GetSymbolInfo(ctx, symbol, baseSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	n.symbols[key] = &cachedSymbolInfo{
+		info:      info,
+		fetchedAt: n.now(),
+	}
+	n.mu.Unlock()
+
+	return info, nil
+}
+
+func symbolKey(exchange commonTypes.Exchange, symbol, baseSymbol string) string {
+	return string(exchange) + ":" + symbol + baseSymbol
+}
+
+// roundToStep rounds value to the nearest multiple of step, or down to it, and cuts float noise to decimals of step
+func roundToStep(value, step float64, down bool) float64 {
+	if step <= 0 {
+		return value
+	}
+
+	steps := math.Round(value / step)
+	if down {
+		// small epsilon keeps values like 0.3/0.1 = 2.9999999999999996 on their step
+		steps = math.Floor(value/step + 1e-9)
+	}
+
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(steps*step, 'f', decimals(step), 64), 64)
+	if err != nil {
+		return steps * step
+	}
+
+	return rounded
+}
+
+// decimals returns number of digits after point step has
+func decimals(step float64) int {
+	formatted := strconv.FormatFloat(step, 'f', -1, 64)
+	point := strings.IndexByte(formatted, '.')
+	if point < 0 {
+		return 0
+	}
+
+	return len(formatted) - point - 1
+}
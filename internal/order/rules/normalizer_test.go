@@ -0,0 +1,78 @@
+package rules_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order/rules"
+	"trade_bot/internal/order/types"
+	commonTypes "trade_bot/internal/types"
+)
+
+type symbolClient struct {
+	client.Client
+	calls int
+}
+
+func (s *symbolClient) Name() commonTypes.Exchange {
+	return commonTypes.ExchangeMexc
+}
+
+func (s *symbolClient) GetSymbolInfo(_ context.Context, symbol, baseSymbol string) (*clientTypes.SymbolInfo, error) {
+	s.calls++
+
+	return &clientTypes.SymbolInfo{
+		Symbol:      symbol,
+		BaseSymbol:  baseSymbol,
+		TickSize:    0.001,
+		StepSize:    0.01,
+		MinQuantity: 0.1,
+		MinNotional: 5,
+	}, nil
+}
+
+func TestNormalize(t *testing.T) {
+	exchangeClient := &symbolClient{}
+	normalizer := rules.NewNormalizer()
+
+	price, quantity, err := normalizer.Normalize(context.Background(), exchangeClient, "ETC", "USDT", 18.71549, 1.23999, 18.71549)
+	require.NoError(t, err)
+	assert.Equal(t, 18.715, price)
+	assert.Equal(t, 1.23, quantity)
+
+	// step is kept for values float can't hold exactly
+	_, quantity, err = normalizer.Normalize(context.Background(), exchangeClient, "ETC", "USDT", 18.7, 0.3, 18.7)
+	require.NoError(t, err)
+	assert.Equal(t, 0.3, quantity)
+
+	// market order is checked against reference price
+	price, quantity, err = normalizer.Normalize(context.Background(), exchangeClient, "ETC", "USDT", 0, 0.5, 20)
+	require.NoError(t, err)
+	assert.Zero(t, price)
+	assert.Equal(t, 0.5, quantity)
+
+	assert.Equal(t, 1, exchangeClient.calls, "symbol filters are cached")
+}
+
+func TestNormalizeBelowMinimum(t *testing.T) {
+	normalizer := rules.NewNormalizer()
+
+	_, _, err := normalizer.Normalize(context.Background(), &symbolClient{}, "ETC", "USDT", 18.7, 0.2, 18.7)
+	assert.ErrorIs(t, err, types.ErrOrderBelowMinimum)
+
+	var minimumErr *types.MinimumError
+	if assert.True(t, errors.As(err, &minimumErr)) {
+		assert.Equal(t, "ETCUSDT", minimumErr.Symbol)
+		assert.Equal(t, 5.0, minimumErr.MinNotional)
+		assert.InDelta(t, 3.74, minimumErr.Notional, 1e-9)
+	}
+
+	_, _, err = normalizer.Normalize(context.Background(), &symbolClient{}, "ETC", "USDT", 100, 0.05, 100)
+	assert.ErrorIs(t, err, types.ErrOrderBelowMinimum)
+}
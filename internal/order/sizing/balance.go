@@ -0,0 +1,37 @@
+package sizing
+
+import (
+	"context"
+	"fmt"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+// BalancePercent spends percent of free base symbol balance as margin
+type BalancePercent struct {
+	percent float64
+}
+
+func NewBalancePercent(percent float64) *BalancePercent {
+	return &BalancePercent{
+		percent: percent,
+	}
+}
+
+func (b *BalancePercent) Size(ctx context.Context, exchangeClient client.Client, signal *signalTypes.Signal, entry, leverage float64) (float64, error) {
+	if entry <= 0 || b.percent <= 0 {
+		return 0, types.ErrOrderQuantityInvalid
+	}
+
+	balance, err := exchangeClient.GetAssets(ctx, signal.BaseSymbol)
+	if err != nil {
+		return 0, fmt.Errorf("BalancePercent::Size : %w", err)
+	}
+	if balance <= 0 {
+		return 0, types.ErrOrderBalanceEmpty
+	}
+
+	return balance * b.percent / 100 * leverage / entry, nil
+}
@@ -0,0 +1,28 @@
+package sizing
+
+import (
+	"context"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+// FixedQuote spends the same amount of base symbol (e.g. USDT) as margin for every signal
+type FixedQuote struct {
+	amount float64
+}
+
+func NewFixedQuote(amount float64) *FixedQuote {
+	return &FixedQuote{
+		amount: amount,
+	}
+}
+
+func (f *FixedQuote) Size(_ context.Context, _ client.Client, _ *signalTypes.Signal, entry, leverage float64) (float64, error) {
+	if entry <= 0 || f.amount <= 0 {
+		return 0, types.ErrOrderQuantityInvalid
+	}
+
+	return f.amount * leverage / entry, nil
+}
@@ -0,0 +1,59 @@
+package sizing
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"trade_bot/internal/client"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+// Risk sizes position so that reaching signal stop loses percent of account equity.
+// Equity is portfolio value for clients able to return it, free base symbol balance otherwise.
+// Margin of position never exceeds free balance.
+type Risk struct {
+	percent float64
+}
+
+func NewRisk(percent float64) *Risk {
+	return &Risk{
+		percent: percent,
+	}
+}
+
+func (r *Risk) Size(ctx context.Context, exchangeClient client.Client, signal *signalTypes.Signal, entry, leverage float64) (float64, error) {
+	if entry <= 0 || r.percent <= 0 || leverage <= 0 {
+		return 0, types.ErrOrderQuantityInvalid
+	}
+
+	stopDistance := math.Abs(entry - signal.Stop)
+	if signal.Stop <= 0 || stopDistance == 0 {
+		return 0, types.ErrOrderStopInvalid
+	}
+
+	balance, err := exchangeClient.GetAssets(ctx, signal.BaseSymbol)
+	if err != nil {
+		return 0, fmt.Errorf("Risk::Size : %w", err)
+	}
+	if balance <= 0 {
+		return 0, types.ErrOrderBalanceEmpty
+	}
+
+	equity := balance
+	if portfolioClient, ok := exchangeClient.(client.PortfolioClient); ok {
+		portfolio, err := portfolioClient.GetPortfolio(ctx, signal.BaseSymbol)
+		if err != nil {
+			return 0, fmt.Errorf("Risk::Size : %w", err)
+		}
+		equity = math.Max(equity, portfolio.Value)
+	}
+
+	quantity := equity * r.percent / 100 / stopDistance
+
+	// leverage limits how large position the balance can hold
+	maxQuantity := balance * leverage / entry
+
+	return math.Min(quantity, maxQuantity), nil
+}
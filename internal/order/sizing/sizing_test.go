@@ -0,0 +1,88 @@
+package sizing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"trade_bot/internal/client"
+	clientTypes "trade_bot/internal/client/types"
+	"trade_bot/internal/order/sizing"
+	"trade_bot/internal/order/types"
+	signalTypes "trade_bot/internal/signals/types"
+)
+
+type balanceClient struct {
+	client.Client
+	balance float64
+}
+
+func (b *balanceClient) GetAssets(_ context.Context, _ string) (float64, error) {
+	return b.balance, nil
+}
+
+type portfolioClient struct {
+	balanceClient
+	value float64
+}
+
+func (p *portfolioClient) GetPortfolio(_ context.Context, quoteSymbol string) (*clientTypes.Portfolio, error) {
+	return &clientTypes.Portfolio{QuoteSymbol: quoteSymbol, Value: p.value}, nil
+}
+
+func TestFixedQuote(t *testing.T) {
+	quantity, err := sizing.NewFixedQuote(100).Size(context.Background(), nil, &signalTypes.Signal{}, 20, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, quantity)
+}
+
+func TestBalancePercent(t *testing.T) {
+	signal := &signalTypes.Signal{BaseSymbol: "USDT"}
+
+	quantity, err := sizing.NewBalancePercent(10).Size(context.Background(), &balanceClient{balance: 1000}, signal, 20, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, quantity)
+
+	_, err = sizing.NewBalancePercent(10).Size(context.Background(), &balanceClient{}, signal, 20, 2)
+	assert.ErrorIs(t, err, types.ErrOrderBalanceEmpty)
+}
+
+func TestRisk(t *testing.T) {
+	exchangeClient := &balanceClient{balance: 1000}
+
+	// 1% of 1000 is lost when price goes 2 down to stop
+	quantity, err := sizing.NewRisk(1).Size(context.Background(), exchangeClient, &signalTypes.Signal{Stop: 18}, 20, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, quantity)
+
+	// short stop is above entry
+	quantity, err = sizing.NewRisk(1).Size(context.Background(), exchangeClient, &signalTypes.Signal{Stop: 22}, 20, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, quantity)
+
+	// balance without leverage holds only 50 of symbol
+	quantity, err = sizing.NewRisk(10).Size(context.Background(), exchangeClient, &signalTypes.Signal{Stop: 19.9}, 20, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, quantity)
+
+	_, err = sizing.NewRisk(1).Size(context.Background(), exchangeClient, &signalTypes.Signal{}, 20, 10)
+	assert.ErrorIs(t, err, types.ErrOrderStopInvalid)
+}
+
+func TestRiskPortfolio(t *testing.T) {
+	exchangeClient := &portfolioClient{balanceClient: balanceClient{balance: 100}, value: 1000}
+
+	// 1% of portfolio value 1000 is lost at stop, margin 100 fits in free balance
+	quantity, err := sizing.NewRisk(1).Size(context.Background(), exchangeClient, &signalTypes.Signal{Stop: 18}, 20, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, quantity)
+
+	// free balance without leverage holds only 5 of symbol
+	quantity, err = sizing.NewRisk(1).Size(context.Background(), exchangeClient, &signalTypes.Signal{Stop: 19}, 20, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, quantity)
+
+	_, err = sizing.NewRisk(1).Size(context.Background(), &portfolioClient{value: 1000}, &signalTypes.Signal{Stop: 18}, 20, 10)
+	assert.ErrorIs(t, err, types.ErrOrderBalanceEmpty)
+}
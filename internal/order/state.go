@@ -0,0 +1,187 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/sirupsen/logrus"
+
+	"trade_bot/internal/order/types"
+)
+
+const (
+	TopicOrderCreated         string = "order.created"
+	TopicOrderPending         string = "order.pending"
+	TopicOrderPlaced          string = "order.placed"
+	TopicOrderPartiallyFilled string = "order.partially_filled"
+	TopicOrderFilled          string = "order.filled"
+	TopicOrderCancelled       string = "order.cancelled"
+	TopicOrderRejected        string = "order.rejected"
+	TopicOrderClosing         string = "order.closing"
+	TopicOrderClosed          string = "order.closed"
+	TopicOrderExpired         string = "order.expired"
+	TopicOrderMissed          string = "order.missed"
+)
+
+var (
+	orderTransitions = map[types.OrderStatus][]types.OrderStatus{
+		types.OrderStatusNew: {
+			types.OrderStatusPending,
+			types.OrderStatusPlaced,
+			types.OrderStatusCancelled,
+			types.OrderStatusRejected,
+		},
+		types.OrderStatusPending: {
+			types.OrderStatusPlaced,
+			types.OrderStatusCancelled,
+			types.OrderStatusRejected,
+		},
+		types.OrderStatusPlaced: {
+			types.OrderStatusPartiallyFilled,
+			types.OrderStatusFilled,
+			types.OrderStatusCancelled,
+			types.OrderStatusRejected,
+			// position may be closed before fills of its legs are known
+			types.OrderStatusClosing,
+			types.OrderStatusExpired,
+			types.OrderStatusMissed,
+		},
+		types.OrderStatusPartiallyFilled: {
+			types.OrderStatusPartiallyFilled,
+			types.OrderStatusFilled,
+			types.OrderStatusCancelled,
+			types.OrderStatusClosing,
+			types.OrderStatusClosed,
+			// leg is expired or missed with its fill kept
+			types.OrderStatusExpired,
+			types.OrderStatusMissed,
+		},
+		types.OrderStatusFilled: {
+			types.OrderStatusClosing,
+			types.OrderStatusClosed,
+		},
+		types.OrderStatusClosing: {
+			types.OrderStatusClosed,
+		},
+		// partially filled order may be cancelled with open position left
+		types.OrderStatusCancelled: {
+			types.OrderStatusClosed,
+		},
+	}
+
+	orderTopics = map[types.OrderStatus]string{
+		types.OrderStatusNew:             TopicOrderCreated,
+		types.OrderStatusPending:         TopicOrderPending,
+		types.OrderStatusPlaced:          TopicOrderPlaced,
+		types.OrderStatusPartiallyFilled: TopicOrderPartiallyFilled,
+		types.OrderStatusFilled:          TopicOrderFilled,
+		types.OrderStatusCancelled:       TopicOrderCancelled,
+		types.OrderStatusRejected:        TopicOrderRejected,
+		types.OrderStatusClosing:         TopicOrderClosing,
+		types.OrderStatusClosed:          TopicOrderClosed,
+		types.OrderStatusExpired:         TopicOrderExpired,
+		types.OrderStatusMissed:          TopicOrderMissed,
+	}
+)
+
+type stateRepository interface {
+	Create(ctx context.Context, order *types.Order) error
+	UpdateStatus(ctx context.Context, order *types.Order, status types.OrderStatus) error
+}
+
+type StateMachineOptions struct {
+	OrderRepository stateRepository
+	Publisher       message.Publisher
+	Logger          *logrus.Logger
+}
+
+// StateMachine is the only place where order status changes. It rejects illegal
+// transitions, stores every change and publishes it to order topics.
+type StateMachine struct {
+	orderRepository stateRepository
+	publisher       message.Publisher
+	log             *logrus.Logger
+}
+
+func NewStateMachine(opt *StateMachineOptions) *StateMachine {
+	return &StateMachine{
+		orderRepository: opt.OrderRepository,
+		publisher:       opt.Publisher,
+		log:             opt.Logger,
+	}
+}
+
+// CanTransition reports whether order in status from may be moved to status to
+func CanTransition(from, to types.OrderStatus) bool {
+	for _, status := range orderTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Create saves new order and publishes it to order.created topic
+func (s *StateMachine) Create(ctx context.Context, order *types.Order) error {
+	if order.Status != types.OrderStatusNew {
+		return fmt.Errorf("StateMachine::Create : %w", types.ErrOrderTransitionInvalid)
+	}
+
+	if err := s.orderRepository.Create(ctx, order); err != nil {
+		return fmt.Errorf("StateMachine::Create : %w", err)
+	}
+
+	s.publish(order, "")
+
+	return nil
+}
+
+// Transition moves order to status and publishes the change to topic of the new status
+func (s *StateMachine) Transition(ctx context.Context, order *types.Order, status types.OrderStatus) error {
+	from := order.Status
+	if !CanTransition(from, status) {
+		return fmt.Errorf("StateMachine::Transition %s -> %s : %w", from, status, types.ErrOrderTransitionInvalid)
+	}
+
+	if err := s.orderRepository.UpdateStatus(ctx, order, status); err != nil {
+		return fmt.Errorf("StateMachine::Transition : %w", err)
+	}
+
+	s.publish(order, from)
+
+	return nil
+}
+
+// publish sends order event, failure is only logged because the change is already stored
+func (s *StateMachine) publish(order *types.Order, from types.OrderStatus) {
+	log := s.log.WithFields(logrus.Fields{
+		"OrderUUID": order.UUID,
+		"From":      from,
+		"To":        order.Status,
+	})
+
+	rawMessage, err := json.Marshal(&types.OrderEvent{
+		Order:     *order,
+		From:      from,
+		To:        order.Status,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal order event")
+
+		return
+	}
+
+	if err := s.publisher.Publish(orderTopics[order.Status], message.NewMessage(watermill.NewUUID(), rawMessage)); err != nil {
+		log.WithError(err).Error("Failed to publish order event")
+
+		return
+	}
+
+	log.Debug("Order event published")
+}
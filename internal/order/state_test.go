@@ -0,0 +1,245 @@
+package order_test
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade_bot/internal/order"
+	"trade_bot/internal/order/types"
+)
+
+// memoryRepository keeps orders in memory the way gorm repository keeps them in database
+type memoryRepository struct {
+	mu     sync.Mutex
+	orders map[uuid.UUID]types.Order
+}
+
+func newMemoryRepository(orders ...types.Order) *memoryRepository {
+	repository := &memoryRepository{orders: map[uuid.UUID]types.Order{}}
+	for _, o := range orders {
+		repository.orders[o.UUID] = o
+	}
+
+	return repository
+}
+
+func (m *memoryRepository) Create(_ context.Context, o *types.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.orders[o.UUID] = *o
+
+	return nil
+}
+
+func (m *memoryRepository) UpdateStatus(_ context.Context, o *types.Order, status types.OrderStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.orders[o.UUID].Status != o.Status {
+		return types.ErrOrderStatusConflict
+	}
+	o.Status = status
+	m.orders[o.UUID] = *o
+
+	return nil
+}
+
+func (m *memoryRepository) FindPositions(_ context.Context, statuses ...types.OrderStatus) ([]*types.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var positions []*types.Order
+	for _, o := range m.orders {
+		for _, status := range statuses {
+			if o.ParentUUID == uuid.Nil && o.Status == status {
+				position := o
+				positions = append(positions, &position)
+			}
+		}
+	}
+
+	return positions, nil
+}
+
+func (m *memoryRepository) FindLegs(_ context.Context, parentUUID uuid.UUID) ([]*types.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var legs []*types.Order
+	for _, o := range m.orders {
+		if o.ParentUUID == parentUUID {
+			leg := o
+			legs = append(legs, &leg)
+		}
+	}
+	sort.Slice(legs, func(i, j int) bool { return legs[i].Leg < legs[j].Leg })
+
+	return legs, nil
+}
+
+func (m *memoryRepository) FindBySignal(_ context.Context, signalUUID uuid.UUID) ([]*types.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var orders []*types.Order
+	for _, o := range m.orders {
+		if o.SignalUUID == signalUUID {
+			stored := o
+			orders = append(orders, &stored)
+		}
+	}
+
+	return orders, nil
+}
+
+func (m *memoryRepository) UpdateFill(_ context.Context, o *types.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.orders[o.UUID]
+	stored.FilledQuantity = o.FilledQuantity
+	stored.AveragePrice = o.AveragePrice
+	stored.Fee = o.Fee
+	stored.QuantityFee = o.QuantityFee
+	m.orders[o.UUID] = stored
+
+	return nil
+}
+
+func (m *memoryRepository) Update(_ context.Context, o *types.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.orders[o.UUID]
+	stored.ClosedQuantity = o.ClosedQuantity
+	stored.Stop = o.Stop
+	stored.StopMoved = o.StopMoved
+	stored.BestPrice = o.BestPrice
+	stored.RealisedPnl = o.RealisedPnl
+	m.orders[o.UUID] = stored
+
+	return nil
+}
+
+func (m *memoryRepository) Get(_ context.Context, orderUUID uuid.UUID) (*types.Order, error) {
+	o := m.get(orderUUID)
+
+	return &o, nil
+}
+
+func (m *memoryRepository) TakeTarget(_ context.Context, o *types.Order, quantity float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.orders[o.UUID]
+	if stored.Status != o.Status || stored.TargetsHit != o.TargetsHit {
+		return types.ErrOrderStatusConflict
+	}
+	o.TargetsHit++
+	o.ClosedQuantity += quantity
+	m.orders[o.UUID] = *o
+
+	return nil
+}
+
+func (m *memoryRepository) ReleaseTarget(_ context.Context, o *types.Order, quantity float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.orders[o.UUID]
+	if stored.TargetsHit != o.TargetsHit {
+		return types.ErrOrderStatusConflict
+	}
+	stored.TargetsHit--
+	stored.ClosedQuantity -= quantity
+	m.orders[o.UUID] = stored
+	o.TargetsHit = stored.TargetsHit
+	o.ClosedQuantity = stored.ClosedQuantity
+
+	return nil
+}
+
+func (m *memoryRepository) get(orderUUID uuid.UUID) types.Order {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.orders[orderUUID]
+}
+
+func (m *memoryRepository) setStatus(orderUUID uuid.UUID, status types.OrderStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.orders[orderUUID]
+	stored.Status = status
+	m.orders[orderUUID] = stored
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(_ string, _ ...*message.Message) error { return nil }
+func (nopPublisher) Close() error                                  { return nil }
+
+func newTestStates(repository *memoryRepository) *order.StateMachine {
+	return order.NewStateMachine(&order.StateMachineOptions{
+		OrderRepository: repository,
+		Publisher:       nopPublisher{},
+		Logger:          logrus.New(),
+	})
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, order.CanTransition(types.OrderStatusNew, types.OrderStatusPending))
+	assert.True(t, order.CanTransition(types.OrderStatusPlaced, types.OrderStatusFilled))
+	assert.True(t, order.CanTransition(types.OrderStatusFilled, types.OrderStatusClosed))
+
+	assert.False(t, order.CanTransition(types.OrderStatusFilled, types.OrderStatusNew))
+	assert.False(t, order.CanTransition(types.OrderStatusClosed, types.OrderStatusPlaced))
+	assert.False(t, order.CanTransition(types.OrderStatusRejected, types.OrderStatusPlaced))
+}
+
+func TestStateMachineTransition(t *testing.T) {
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	filled, err := pubSub.Subscribe(context.Background(), order.TopicOrderFilled)
+	require.NoError(t, err)
+
+	states := order.NewStateMachine(&order.StateMachineOptions{
+		OrderRepository: newMemoryRepository(),
+		Publisher:       pubSub,
+		Logger:          logrus.New(),
+	})
+
+	o := &types.Order{UUID: uuid.New(), Status: types.OrderStatusNew}
+	require.NoError(t, states.Create(context.Background(), o))
+	require.NoError(t, states.Transition(context.Background(), o, types.OrderStatusPlaced))
+	require.NoError(t, states.Transition(context.Background(), o, types.OrderStatusFilled))
+
+	select {
+	case msg := <-filled:
+		var event types.OrderEvent
+		require.NoError(t, json.Unmarshal(msg.Payload, &event))
+		assert.Equal(t, o.UUID, event.Order.UUID)
+		assert.Equal(t, types.OrderStatusPlaced, event.From)
+		assert.Equal(t, types.OrderStatusFilled, event.To)
+		msg.Ack()
+	case <-time.After(time.Second):
+		t.Fatal("order.filled event is not published")
+	}
+
+	err = states.Transition(context.Background(), o, types.OrderStatusNew)
+	assert.ErrorIs(t, err, types.ErrOrderTransitionInvalid)
+	assert.Equal(t, types.OrderStatusFilled, o.Status)
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"trade_bot/internal/client"
+)
+
+var (
+	ErrOrderNotFound              = errors.New("order not found")
+	ErrOrderStatusConflict        = errors.New("order status changed concurrently")
+	ErrOrderTransitionInvalid     = errors.New("order status transition invalid")
+	ErrOrderEntryNotFound         = errors.New("order entry not found")
+	ErrOrderEntryInvalid          = errors.New("order entry interval invalid")
+	ErrOrderQuantityInvalid       = errors.New("order quantity invalid")
+	ErrOrderStopInvalid           = errors.New("order stop invalid")
+	ErrOrderBalanceEmpty          = errors.New("order balance empty")
+	ErrOrderChannelNotFound       = errors.New("order channel options not found")
+	ErrOrderLegsNotPlaced         = errors.New("order legs not placed")
+	ErrOrderLegsPending           = errors.New("order legs not placed yet")
+	ErrOrderLegsInvalid           = errors.New("order legs invalid")
+	ErrOrderTypeNotSupported      = errors.New("order type not supported by exchange")
+	ErrOrderPositionNotSupported  = errors.New("order position not supported by exchange")
+	ErrOrderTargetsInvalid        = errors.New("order targets invalid")
+	ErrOrderCandlesNotSupported   = errors.New("order exchange has no candles")
+	ErrOrderBelowMinimum          = errors.New("order below exchange minimum")
+	ErrOrderRiskRejected          = errors.New("order rejected by risk limits")
+	ErrOrderEntrySlipped          = errors.New("order price left entry interval")
+	ErrOrderTargetPassed          = errors.New("order target already passed by price")
+	ErrOrderStopPassed            = errors.New("order stop already passed by price")
+	ErrOrderStopBeyondLiquidation = errors.New("order stop lies beyond liquidation price")
+)
+
+// permanentErrors never pass when signal is processed again, they come from signal itself,
+// from channel options or from what exchange can't take at all
+var permanentErrors = []error{
+	ErrOrderChannelNotFound,
+	ErrOrderEntryNotFound,
+	ErrOrderEntryInvalid,
+	ErrOrderQuantityInvalid,
+	ErrOrderStopInvalid,
+	ErrOrderBalanceEmpty,
+	ErrOrderLegsNotPlaced,
+	ErrOrderLegsInvalid,
+	ErrOrderTypeNotSupported,
+	ErrOrderPositionNotSupported,
+	ErrOrderTargetsInvalid,
+	ErrOrderBelowMinimum,
+	ErrOrderRiskRejected,
+	ErrOrderTransitionInvalid,
+	client.ErrClientNotFound,
+}
+
+// IsRetryable reports whether the same work failed with err may succeed when done again.
+// Exchange errors of auth, balance and symbol category are permanent, unknown ones are
+// treated as transient.
+func IsRetryable(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+
+	switch client.CategoryOf(err) {
+	case client.ErrorCategoryAuth, client.ErrorCategoryBalance, client.ErrorCategorySymbol:
+		return false
+	default:
+		return true
+	}
+}
+
+// MinimumError tells which exchange minimum of symbol order doesn't meet, it matches ErrOrderBelowMinimum
+type MinimumError struct {
+	Symbol      string
+	Quantity    float64
+	MinQuantity float64
+	Notional    float64
+	MinNotional float64
+}
+
+func (e *MinimumError) Error() string {
+	return fmt.Sprintf(
+		"%s: quantity %g (min %g), notional %g (min %g)",
+		ErrOrderBelowMinimum, e.Quantity, e.MinQuantity, e.Notional, e.MinNotional,
+	)
+}
+
+func (e *MinimumError) Unwrap() error {
+	return ErrOrderBelowMinimum
+}
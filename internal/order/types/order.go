@@ -1,6 +1,8 @@
 package types
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,24 +12,198 @@ import (
 
 type OrderStatus string
 
-const OrderStatusNew OrderStatus = "new"
+const (
+	// OrderStatusNew order is created from signal and saved to storage
+	OrderStatusNew OrderStatus = "new"
+	// OrderStatusPending order is being sent to exchange
+	OrderStatusPending OrderStatus = "pending"
+	// OrderStatusPlaced order is accepted by exchange and waits for fill
+	OrderStatusPlaced          OrderStatus = "placed"
+	OrderStatusPartiallyFilled OrderStatus = "partially_filled"
+	OrderStatusFilled          OrderStatus = "filled"
+	OrderStatusCancelled       OrderStatus = "cancelled"
+	OrderStatusRejected        OrderStatus = "rejected"
+	// OrderStatusClosing position is claimed to be closed, exit orders are being sent
+	OrderStatusClosing OrderStatus = "closing"
+	// OrderStatusClosed position opened by order is closed
+	OrderStatusClosed OrderStatus = "closed"
+	// OrderStatusExpired entry is cancelled as it wasn't filled within entry TTL of channel
+	OrderStatusExpired OrderStatus = "expired"
+	// OrderStatusMissed entry is cancelled as price reached target before it was filled
+	OrderStatusMissed OrderStatus = "missed"
+)
 
+// Order is either a position built from signal or, when ParentUUID is set, one of orders the position is entered with
 type Order struct {
 	UUID       uuid.UUID
+	ParentUUID uuid.UUID
+	Leg        int
 	SignalUUID uuid.UUID
+	Channel    commonTypes.SignalChannel
 	CreatedAt  time.Time
+	UpdatedAt  time.Time
 	Exchange   commonTypes.Exchange
 
+	ExchangeOrderID commonTypes.OrderID
+	// ClientOrderID is sent to exchange with leg order, it is derived from signal so a replayed signal finds its orders
+	ClientOrderID string
+
 	Symbol     string
 	BaseSymbol string
 	Position   commonTypes.Position
+	// Type is how leg order is sent to exchange, empty is limit
+	Type commonTypes.OrderType
 
 	Leverage float64
 	Entry    float64
 	Quantity float64
 
-	Target float64
-	Stop   float64
+	// FilledQuantity is part of quantity filled on exchange, AveragePrice is the average price it is filled at.
+	// Position sums fills of its legs.
+	FilledQuantity float64
+	AveragePrice   float64
+	// Fee is commission exchange charged for fills in quote asset, QuantityFee is commission
+	// taken from filled asset, it is never held and so never closed
+	Fee         float64
+	QuantityFee float64
+
+	Targets []Target
+	Stop    float64
+
+	// TargetsHit is how many of targets position is already partially closed on
+	TargetsHit int
+	// ClosedQuantity is part of quantity already closed on targets
+	ClosedQuantity float64
+	// BestPrice is the best price seen while position is open, trailing stops follow it
+	BestPrice float64
+	// StopMoved is set once stop differs from the one signal gave. Manager closes position on moved stop,
+	// futures exchanges able to move stop loss get it as well.
+	StopMoved bool
+	// RealisedPnl is profit of closed part in base symbol, estimated by price exit orders are sent at without fees
+	RealisedPnl float64
 
 	Status OrderStatus
 }
+
+// Target is a take profit level, Percent is a share of position quantity closed on it
+type Target struct {
+	Price   float64
+	Percent float64
+}
+
+// NewClientOrderID returns client order id of leg of position built from signal, the same for every replay of signal
+func NewClientOrderID(signalUUID uuid.UUID, leg int) string {
+	return fmt.Sprintf("%s-%d", strings.ReplaceAll(signalUUID.String(), "-", ""), leg)
+}
+
+// NextTarget returns the first target position is not closed on yet, nil when all are taken
+func (o *Order) NextTarget() *Target {
+	if o.TargetsHit >= len(o.Targets) {
+		return nil
+	}
+
+	return &o.Targets[o.TargetsHit]
+}
+
+// NextTargetQuantity returns filled quantity to close on next target, the last target closes the rest of position
+func (o *Order) NextTargetQuantity() float64 {
+	target := o.NextTarget()
+	if target == nil {
+		return 0
+	}
+
+	if o.TargetsHit == len(o.Targets)-1 {
+		return o.OpenQuantity()
+	}
+
+	return o.HeldQuantity() * target.Percent / 100
+}
+
+// EntryPrice returns average price position is filled at, planned entry while no fill is known
+func (o *Order) EntryPrice() float64 {
+	if o.AveragePrice > 0 {
+		return o.AveragePrice
+	}
+
+	return o.Entry
+}
+
+// HeldQuantity returns filled quantity account holds after commission taken from it
+func (o *Order) HeldQuantity() float64 {
+	return o.FilledQuantity - o.QuantityFee
+}
+
+// OpenQuantity returns held quantity not closed yet
+func (o *Order) OpenQuantity() float64 {
+	return o.HeldQuantity() - o.ClosedQuantity
+}
+
+// TargetReached reports whether price got to next target of position
+func (o *Order) TargetReached(price float64) bool {
+	target := o.NextTarget()
+	if target == nil || target.Price <= 0 {
+		return false
+	}
+
+	if o.Position == commonTypes.PositionShort {
+		return price <= target.Price
+	}
+
+	return price >= target.Price
+}
+
+// Pnl returns profit of closing quantity of position at price
+func (o *Order) Pnl(price, quantity float64) float64 {
+	entry := o.AveragePrice
+	if entry <= 0 {
+		entry = o.Entry
+	}
+
+	if o.Position == commonTypes.PositionShort {
+		return (entry - price) * quantity
+	}
+
+	return (price - entry) * quantity
+}
+
+// Exposure returns notional of position not closed yet
+func (o *Order) Exposure() float64 {
+	return (o.Quantity - o.ClosedQuantity) * o.Entry
+}
+
+// Better reports whether price a is more profitable for position than price b
+func (o *Order) Better(a, b float64) bool {
+	if o.Position == commonTypes.PositionShort {
+		return a < b
+	}
+
+	return a > b
+}
+
+// StopReached reports whether price got to stop of position
+func (o *Order) StopReached(price float64) bool {
+	if o.Stop <= 0 {
+		return false
+	}
+
+	if o.Position == commonTypes.PositionShort {
+		return price >= o.Stop
+	}
+
+	return price <= o.Stop
+}
+
+// EntryLeg is a part of position entered by a separate order, limit order unless Type says otherwise
+type EntryLeg struct {
+	Type     commonTypes.OrderType
+	Price    float64
+	Quantity float64
+}
+
+// OrderEvent is published to order topics on every status change
+type OrderEvent struct {
+	Order     Order
+	From      OrderStatus
+	To        OrderStatus
+	CreatedAt time.Time
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	commonTypes "trade_bot/internal/types"
+)
+
+// RejectionReason is the risk limit position broke
+type RejectionReason string
+
+const (
+	RejectionReasonOpenPositions   RejectionReason = "max_open_positions"
+	RejectionReasonSymbolExposure  RejectionReason = "max_symbol_exposure"
+	RejectionReasonChannelExposure RejectionReason = "max_channel_exposure"
+	RejectionReasonDailyLoss       RejectionReason = "max_daily_loss"
+)
+
+// Rejection is a record of position risk manager didn't let to exchange
+type Rejection struct {
+	OrderUUID  uuid.UUID
+	SignalUUID uuid.UUID
+	Channel    commonTypes.SignalChannel
+	Exchange   commonTypes.Exchange
+	Symbol     string
+	BaseSymbol string
+	Reason     RejectionReason
+	// Value is what position would bring limit to, Limit is the limit itself
+	Value     float64
+	Limit     float64
+	CreatedAt time.Time
+}
+
+// RiskError tells which risk limit position breaks, it matches ErrOrderRiskRejected
+type RiskError struct {
+	Reason RejectionReason
+	Value  float64
+	Limit  float64
+}
+
+func (e *RiskError) Error() string {
+	return fmt.Sprintf("%s: %s %g over %g", ErrOrderRiskRejected, e.Reason, e.Value, e.Limit)
+}
+
+func (e *RiskError) Unwrap() error {
+	return ErrOrderRiskRejected
+}
@@ -8,6 +8,7 @@ import (
 
 	chatTypes "trade_bot/internal/chat/types"
 	"trade_bot/internal/signals/parser"
+	"trade_bot/internal/signals/types"
 	commonTypes "trade_bot/internal/types"
 )
 
@@ -41,7 +42,7 @@ func TestHardcoreVIPParseLong(t *testing.T) {
 		assert.Equal(t, 18.715, signal.EntryInterval.Max)
 	}
 
-	assert.Equal(t, 18.902, signal.Target)
+	assert.Equal(t, []types.Target{{Price: 18.902}}, signal.Targets)
 	assert.Equal(t, 17.592, signal.Stop)
 }
 
@@ -75,7 +76,7 @@ func TestHardcoreVIPParseShort(t *testing.T) {
 		assert.Equal(t, 18.715, signal.EntryInterval.Max)
 	}
 
-	assert.Equal(t, 17.902, signal.Target)
+	assert.Equal(t, []types.Target{{Price: 17.902}}, signal.Targets)
 	assert.Equal(t, 18.592, signal.Stop)
 }
 
@@ -103,6 +104,34 @@ func TestHardcoreVIPParsePosition(t *testing.T) {
 		assert.Equal(t, 181.42, signal.EntryInterval.Max)
 	}
 
-	assert.Equal(t, 183.23, signal.Target)
+	assert.Equal(t, []types.Target{{Price: 183.23}}, signal.Targets)
 	assert.Equal(t, 170.53, signal.Stop)
 }
+
+func TestHardcoreVIPParseTargets(t *testing.T) {
+	text := `📈 LONG
+ 
+	▪️Монета: ETC
+	▪️Плечо: 25-50х
+	▪️Вход: от 18.715 до 18.154
+	▪️Цель 1: 18.902 (50%)
+	▪️Цель 2: 19.1
+	▪️Цель 3: 19.5
+	▪️Стоп: 17.592`
+
+	handler := parser.NewHardcoreVIP()
+
+	signal, err := handler.ParseSignal(context.Background(), &chatTypes.ChatIncomingMessage{
+		Text: text,
+	})
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, signal) {
+		assert.Equal(t, []types.Target{
+			{Price: 18.902, Percent: 50},
+			{Price: 19.1},
+			{Price: 19.5},
+		}, signal.Targets)
+		assert.Equal(t, 17.592, signal.Stop)
+	}
+}
@@ -94,15 +94,27 @@ func (h *HardcoreVIP) ParseSignal(ctx context.Context, chatMessage *chatTypes.Ch
 	}
 	signal.EntryInterval = commonTypes.NewInterval(entryMin, entryMax)
 
-	// target
-	targetPattern := regexp.MustCompile(`Цель:\s*([\d.]+)\n`)
-	targetMatches := targetPattern.FindStringSubmatch(message)
-	if len(targetMatches) != 2 {
+	// targets, channel posts either a single "Цель" or numbered ones with optional share "Цель 1: 1.5 (30%)"
+	targetPattern := regexp.MustCompile(`Цель(?:\s*\d+)?:\s*([\d.]+)(?:\s*\(([\d.]+)%\))?`)
+	targetMatches := targetPattern.FindAllStringSubmatch(message, -1)
+	if len(targetMatches) == 0 {
 		return nil, types.ErrParseTargetNotFound
 	}
-	signal.Target, err = strconv.ParseFloat(targetMatches[1], 64)
-	if err != nil {
-		return nil, fmt.Errorf("HardcoreVIP::ParseSignal : %w", err)
+	for _, match := range targetMatches {
+		var target types.Target
+
+		target.Price, err = strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("HardcoreVIP::ParseSignal : %w", err)
+		}
+		if match[2] != "" {
+			target.Percent, err = strconv.ParseFloat(match[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("HardcoreVIP::ParseSignal : %w", err)
+			}
+		}
+
+		signal.Targets = append(signal.Targets, target)
 	}
 
 	// stop
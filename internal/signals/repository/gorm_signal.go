@@ -24,8 +24,9 @@ type gormSignalEntity struct {
 	LeverageIntervalTo   *float64
 	EntryIntervalFrom    *float64
 	EntryIntervalTo      *float64
-	Target               float64
+	Targets              []types.Target `gorm:"serializer:json"`
 	Stop                 float64
+	Status               types.SignalStatus
 }
 
 func newEntityFromSignal(signal *types.Signal) *gormSignalEntity {
@@ -37,8 +38,9 @@ func newEntityFromSignal(signal *types.Signal) *gormSignalEntity {
 		Symbol:     signal.Symbol,
 		BaseSymbol: signal.BaseSymbol,
 		Position:   signal.Position,
-		Target:     signal.Target,
+		Targets:    signal.Targets,
 		Stop:       signal.Stop,
+		Status:     signal.Status,
 	}
 
 	if signal.EntryInterval != nil {
@@ -64,12 +66,47 @@ func NewGormSignal(
 	if err := db.AutoMigrate(&gormSignalEntity{}); err != nil {
 		return nil, fmt.Errorf("NewGormSignal : %w", err)
 	}
+	if err := migrateTargets(db); err != nil {
+		return nil, fmt.Errorf("NewGormSignal : %w", err)
+	}
 
 	return &GormSignal{
 		db: db,
 	}, nil
 }
 
+// migrateTargets moves take profit of signals stored before multiple targets were supported
+// from legacy target column to targets. Signals that already have targets are left as they are.
+func migrateTargets(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&gormSignalEntity{}, "target") {
+		return nil
+	}
+
+	var legacy []struct {
+		UUID   uuid.UUID
+		Target float64
+	}
+	if err := db.Model(&gormSignalEntity{}).
+		Select("uuid", "target").
+		Where("targets IS NULL AND target <> 0").
+		Scan(&legacy).Error; err != nil {
+		return fmt.Errorf("migrateTargets : %w", err)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, signal := range legacy {
+			if err := tx.Model(&gormSignalEntity{}).
+				Where("uuid = ?", signal.UUID).
+				Select("Targets").
+				Updates(&gormSignalEntity{Targets: []types.Target{{Price: signal.Target}}}).Error; err != nil {
+				return fmt.Errorf("migrateTargets : %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
 func (g *GormSignal) Create(ctx context.Context, signal *types.Signal) error {
 	if err := g.db.WithContext(ctx).Create(newEntityFromSignal(signal)).Error; err != nil {
 		return fmt.Errorf("GormSignal::Create : %w", err)
@@ -77,3 +114,19 @@ func (g *GormSignal) Create(ctx context.Context, signal *types.Signal) error {
 
 	return nil
 }
+
+// UpdateStatus records what became of signal
+func (g *GormSignal) UpdateStatus(ctx context.Context, signalUUID uuid.UUID, status types.SignalStatus) error {
+	result := g.db.WithContext(ctx).
+		Model(&gormSignalEntity{}).
+		Where("uuid = ?", signalUUID).
+		Update("status", status)
+	if result.Error != nil {
+		return fmt.Errorf("GormSignal::UpdateStatus : %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("GormSignal::UpdateStatus : %w", types.ErrSignalNotFound)
+	}
+
+	return nil
+}
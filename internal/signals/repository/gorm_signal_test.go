@@ -0,0 +1,43 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"trade_bot/internal/signals/repository"
+	"trade_bot/internal/signals/types"
+)
+
+func TestNewGormSignalMigratesLegacyTarget(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "signals.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	// table as it was before multiple targets were supported
+	require.NoError(t, db.Exec(`CREATE TABLE gorm_signal_entities (uuid TEXT PRIMARY KEY, symbol TEXT, target REAL, stop REAL)`).Error)
+	legacy, noTarget := uuid.New(), uuid.New()
+	require.NoError(t, db.Exec(`INSERT INTO gorm_signal_entities (uuid, symbol, target, stop) VALUES (?, 'ETC', 25.5, 18), (?, 'BTC', 0, 18)`, legacy, noTarget).Error)
+
+	// migration runs again on every start
+	for range 2 {
+		_, err = repository.NewGormSignal(db)
+		require.NoError(t, err)
+	}
+
+	var rows []struct {
+		UUID    uuid.UUID
+		Targets []types.Target `gorm:"serializer:json"`
+	}
+	require.NoError(t, db.Table("gorm_signal_entities").Select("uuid", "targets").Order("symbol DESC").Scan(&rows).Error)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, legacy, rows[0].UUID)
+	assert.Equal(t, []types.Target{{Price: 25.5}}, rows[0].Targets)
+	assert.Equal(t, noTarget, rows[1].UUID)
+	assert.Empty(t, rows[1].Targets)
+}
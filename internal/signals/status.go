@@ -0,0 +1,117 @@
+package signals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	orderTypes "trade_bot/internal/order/types"
+	"trade_bot/internal/signals/types"
+)
+
+type signalStatusRepository interface {
+	UpdateStatus(ctx context.Context, signalUUID uuid.UUID, status types.SignalStatus) error
+}
+
+type StatusTrackerOptions struct {
+	OrderSubscriber  message.Subscriber
+	ExpiredTopic     string
+	MissedTopic      string
+	SignalRepository signalStatusRepository
+	Logger           *logrus.Logger
+}
+
+// StatusTracker moves signal to expired or missed status once its position is
+// cancelled for the same reason. Events of entry legs are skipped, only position
+// tells what became of the whole signal. Position partially filled before its other
+// legs expired is filled, so its signal keeps status it has.
+type StatusTracker struct {
+	orderSubscriber  message.Subscriber
+	expiredTopic     string
+	missedTopic      string
+	signalRepository signalStatusRepository
+	log              *logrus.Logger
+}
+
+func NewStatusTracker(opt *StatusTrackerOptions) *StatusTracker {
+	return &StatusTracker{
+		orderSubscriber:  opt.OrderSubscriber,
+		expiredTopic:     opt.ExpiredTopic,
+		missedTopic:      opt.MissedTopic,
+		signalRepository: opt.SignalRepository,
+		log:              opt.Logger,
+	}
+}
+
+func (s *StatusTracker) Start(ctx context.Context) error {
+	expired, err := s.orderSubscriber.Subscribe(ctx, s.expiredTopic)
+	if err != nil {
+		return fmt.Errorf("StatusTracker::Start : %w", err)
+	}
+	missed, err := s.orderSubscriber.Subscribe(ctx, s.missedTopic)
+	if err != nil {
+		return fmt.Errorf("StatusTracker::Start : %w", err)
+	}
+
+	for {
+		var (
+			rawMsg *message.Message
+			status types.SignalStatus
+			ok     bool
+		)
+
+		select {
+		case <-ctx.Done():
+			s.log.Info("Status tracker context cancelled, stopping signal status tracking")
+			return nil
+		case rawMsg, ok = <-expired:
+			status = types.SignalStatusExpired
+		case rawMsg, ok = <-missed:
+			status = types.SignalStatusMissed
+		}
+		if !ok {
+			s.log.Warn("Order channel closed, stopping signal status tracking")
+			return nil
+		}
+
+		log := s.log.WithFields(logrus.Fields{
+			"MessageUUID": rawMsg.UUID,
+			"Status":      status,
+		})
+
+		if err := s.updateStatus(ctx, rawMsg, status, log); err != nil {
+			log.WithError(err).Error("Failed to update signal status")
+		}
+
+		rawMsg.Ack()
+	}
+}
+
+func (s *StatusTracker) Stop(ctx context.Context) error {
+	s.log.Info("Stopping signal status tracker")
+
+	return nil
+}
+
+func (s *StatusTracker) updateStatus(ctx context.Context, rawMsg *message.Message, status types.SignalStatus, log *logrus.Entry) error {
+	var event orderTypes.OrderEvent
+	if err := json.Unmarshal(rawMsg.Payload, &event); err != nil {
+		return fmt.Errorf("StatusTracker::updateStatus : %w", err)
+	}
+
+	// legs are cancelled one by one, position is moved once all of them are done
+	if event.Order.ParentUUID != uuid.Nil {
+		return nil
+	}
+
+	if err := s.signalRepository.UpdateStatus(ctx, event.Order.SignalUUID, status); err != nil {
+		return fmt.Errorf("StatusTracker::updateStatus : %w", err)
+	}
+	log.WithField("SignalUUID", event.Order.SignalUUID).Info("Signal status updated")
+
+	return nil
+}
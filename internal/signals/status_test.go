@@ -0,0 +1,91 @@
+package signals_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	orderTypes "trade_bot/internal/order/types"
+	"trade_bot/internal/signals"
+	"trade_bot/internal/signals/types"
+)
+
+type statusUpdate struct {
+	signalUUID uuid.UUID
+	status     types.SignalStatus
+}
+
+type statusRepository struct {
+	updates chan statusUpdate
+}
+
+func (s *statusRepository) UpdateStatus(_ context.Context, signalUUID uuid.UUID, status types.SignalStatus) error {
+	s.updates <- statusUpdate{signalUUID: signalUUID, status: status}
+
+	return nil
+}
+
+func publishOrderEvent(t *testing.T, publisher message.Publisher, topic string, order orderTypes.Order) {
+	t.Helper()
+
+	rawMessage, err := json.Marshal(&orderTypes.OrderEvent{Order: order, To: order.Status})
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(topic, message.NewMessage(watermill.NewUUID(), rawMessage)))
+}
+
+func TestStatusTracker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// persistent pub sub delivers events published before tracker subscribed
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	repository := &statusRepository{updates: make(chan statusUpdate, 4)}
+	tracker := signals.NewStatusTracker(&signals.StatusTrackerOptions{
+		OrderSubscriber:  pubSub,
+		ExpiredTopic:     "order.expired",
+		MissedTopic:      "order.missed",
+		SignalRepository: repository,
+		Logger:           logrus.New(),
+	})
+
+	position := orderTypes.Order{UUID: uuid.New(), SignalUUID: uuid.New(), Status: orderTypes.OrderStatusMissed}
+	leg := orderTypes.Order{UUID: uuid.New(), ParentUUID: position.UUID, SignalUUID: position.SignalUUID, Status: orderTypes.OrderStatusMissed}
+	expired := orderTypes.Order{UUID: uuid.New(), SignalUUID: uuid.New(), Status: orderTypes.OrderStatusExpired}
+
+	// leg event is skipped, signal is updated once by its position
+	publishOrderEvent(t, pubSub, "order.missed", leg)
+	publishOrderEvent(t, pubSub, "order.missed", position)
+	publishOrderEvent(t, pubSub, "order.expired", expired)
+
+	done := make(chan error)
+	go func() {
+		done <- tracker.Start(ctx)
+	}()
+
+	var updates []statusUpdate
+	for len(updates) < 2 {
+		select {
+		case update := <-repository.updates:
+			updates = append(updates, update)
+		case <-time.After(time.Second):
+			t.Fatal("signal status is not updated")
+		}
+	}
+	assert.ElementsMatch(t, []statusUpdate{
+		{signalUUID: position.SignalUUID, status: types.SignalStatusMissed},
+		{signalUUID: expired.SignalUUID, status: types.SignalStatusExpired},
+	}, updates)
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Empty(t, repository.updates)
+}
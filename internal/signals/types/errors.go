@@ -10,4 +10,5 @@ var (
 	ErrParseTargetNotFound           = errors.New("signal target not found")
 	ErrParseStopNotFound             = errors.New("signal stop not found")
 	ErrSignalHandlerNotFound         = errors.New("signal handler not found")
+	ErrSignalNotFound                = errors.New("signal not found")
 )
@@ -8,6 +8,17 @@ import (
 	commonTypes "trade_bot/internal/types"
 )
 
+// SignalStatus tells what became of signal once it was saved
+type SignalStatus string
+
+const (
+	SignalStatusNew SignalStatus = "new"
+	// SignalStatusExpired entry of signal wasn't filled within entry TTL of channel
+	SignalStatusExpired SignalStatus = "expired"
+	// SignalStatusMissed price reached target of signal before entry was filled
+	SignalStatusMissed SignalStatus = "missed"
+)
+
 type Signal struct {
 	UUID             uuid.UUID
 	CreatedAt        time.Time
@@ -18,13 +29,22 @@ type Signal struct {
 	Position         commonTypes.Position
 	LeverageInterval *commonTypes.Interval
 	EntryInterval    *commonTypes.Interval
-	Target           float64
+	Targets          []Target
 	Stop             float64
+	Status           SignalStatus
+}
+
+// Target is a take profit level. Percent is a share of position closed on it,
+// zero means the share is not given by channel.
+type Target struct {
+	Price   float64
+	Percent float64
 }
 
 func NewSignal() *Signal {
 	return &Signal{
 		UUID:      uuid.New(),
 		CreatedAt: time.Now(),
+		Status:    SignalStatusNew,
 	}
 }
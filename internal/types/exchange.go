@@ -6,6 +6,7 @@ type (
 	Position      string
 	Exchange      string
 	SignalChannel string
+	MarginMode    string
 
 	OrderType string
 )
@@ -21,6 +22,9 @@ const (
 
 	SignalChannelHardcoreVIP SignalChannel = "hardcoreVIP"
 
+	MarginModeIsolated MarginMode = "isolated"
+	MarginModeCross    MarginMode = "cross"
+
 	OrderTypeLimit             OrderType = "limit"
 	OrderTypeMarket            OrderType = "market"
 	OrderTypeLimitMarket       OrderType = "limit_market"
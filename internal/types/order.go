@@ -29,11 +29,19 @@ type OrderCreate struct {
 }
 
 type Order struct {
-	OrderID  string
-	Currency string
-	Side     OrderSide
-	Type     OrderType
-	Quantity float64
-	Price    float64
-	Status   OrderStatus
+	OrderID       string
+	ClientOrderID string
+	Currency      string
+	Side          OrderSide
+	Type          OrderType
+	Quantity      float64
+	Price         float64
+	Status        OrderStatus
+	// ExecutedQuantity is filled part of quantity, AveragePrice is the average price it is filled at
+	ExecutedQuantity float64
+	AveragePrice     float64
+	// Fee is commission paid for filled part in quote asset. QuantityFee is commission taken from
+	// filled asset itself, account receives filled quantity less it.
+	Fee         float64
+	QuantityFee float64
 }